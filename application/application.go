@@ -1,6 +1,7 @@
 package application
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/docStonehenge/exchange_fetcher/connector"
@@ -8,19 +9,56 @@ import (
 	"github.com/docStonehenge/exchange_fetcher/indices"
 	"github.com/docStonehenge/exchange_fetcher/slice"
 	"github.com/joho/godotenv"
+	"github.com/streadway/amqp"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 )
 
 var symbols slice.StringSlice
 var onQueue bool
+var providerName string
+var recordDir, replayDir string
+var schemaVersion int
+var resultFields string
+var connectTimeout, readTimeout, requestTimeout time.Duration
+var retryPolicy exchange.RetryPolicy
+var retryStatusCodes string
+var retryEnvironment = map[string]string{
+	"retries":          "RETRY_MAX_ATTEMPTS",
+	"retry-base-delay": "RETRY_BASE_DELAY",
+	"retry-max-delay":  "RETRY_MAX_DELAY",
+	"retry-jitter":     "RETRY_JITTER",
+	"retry-status":     "RETRY_STATUS_CODES",
+}
+var breakerThreshold int
+var breakerCooldown time.Duration
+var cacheTTL time.Duration
+var chunkSize, chunkWorkers int
+var symbolPattern string
+var aliasesFile string
+var aliases indices.Aliases
+var searchText, directoryFile string
+var directory indices.Directory
+var strictSymbols bool
+var legacyRequests bool
+var quoteCache *exchange.Cache
+var quoteProvider exchange.Provider
 
 func Run() {
 	parseCommandFlags()
 
-	if onQueue {
+	switch {
+	case onQueue:
 		runProcessOnMQ()
-	} else {
+	case searchText != "":
+		logSearchResults()
+	default:
 		logIndicesRequest()
 	}
 }
@@ -31,6 +69,136 @@ func parseCommandFlags() {
 		"Runs application on an open RabbitMQ connection with a client application",
 	)
 
+	flag.StringVar(
+		&providerName, "provider", "",
+		fmt.Sprintf(
+			"Quote provider used to fetch results. Defaults to QUOTE_PROVIDER environment variable or '%s'.\n\tAvailable: %s",
+			exchange.DefaultProvider, strings.Join(exchange.ProviderNames(), ", "),
+		),
+	)
+
+	flag.IntVar(
+		&schemaVersion, "schema", 0,
+		fmt.Sprintf(
+			"Version of JSON results schema. Defaults to RESULTS_SCHEMA_VERSION environment variable or %d.\n\t%d: results keyed by company name\n\t%d: results keyed by symbol\n\t%d: results keyed by symbol, with numeric changes",
+			indices.DefaultVersion, indices.NameKeyedVersion, indices.SymbolKeyedVersion, indices.NumericVersion,
+		),
+	)
+
+	flag.StringVar(
+		&resultFields, "fields", "",
+		fmt.Sprintf(
+			"Comma-separated list of quote fields included on results. Defaults to RESULTS_FIELDS environment variable or every available field.\n\tAvailable on versions %d and %d: %s\n\tAvailable on version %d: %s",
+			indices.NameKeyedVersion, indices.SymbolKeyedVersion, strings.Join(indices.FieldNames(indices.SymbolKeyedVersion), ", "),
+			indices.NumericVersion, strings.Join(indices.FieldNames(indices.NumericVersion), ", "),
+		),
+	)
+
+	flag.DurationVar(
+		&connectTimeout, "connect-timeout", 5*time.Second,
+		"Maximum time to establish a connection with the quote provider",
+	)
+
+	flag.DurationVar(
+		&readTimeout, "read-timeout", 10*time.Second,
+		"Maximum time to wait for the quote provider response once connected",
+	)
+
+	flag.DurationVar(
+		&requestTimeout, "request-timeout", 30*time.Second,
+		"Deadline for fetching all symbols of a single request, including retries",
+	)
+
+	flag.IntVar(
+		&retryPolicy.MaxAttempts, "retries", 3,
+		"Maximum attempts for each request to the quote provider. Use 1 to disable retries. Defaults to RETRY_MAX_ATTEMPTS environment variable or 3",
+	)
+
+	flag.DurationVar(
+		&retryPolicy.BaseDelay, "retry-base-delay", 200*time.Millisecond,
+		"Delay before the first retry, doubled on each following attempt. Defaults to RETRY_BASE_DELAY environment variable or 200ms",
+	)
+
+	flag.DurationVar(
+		&retryPolicy.MaxDelay, "retry-max-delay", 5*time.Second,
+		"Maximum delay between retries. Defaults to RETRY_MAX_DELAY environment variable or 5s",
+	)
+
+	flag.Float64Var(
+		&retryPolicy.Jitter, "retry-jitter", 0.2,
+		"Fraction of each retry delay randomly taken off, between 0 and 1. Defaults to RETRY_JITTER environment variable or 0.2",
+	)
+
+	flag.StringVar(
+		&retryStatusCodes, "retry-status", "429,500,502,503,504",
+		"Comma-separated list of upstream HTTP status codes which are retried. Defaults to RETRY_STATUS_CODES environment variable or 429,500,502,503,504",
+	)
+
+	flag.IntVar(
+		&breakerThreshold, "breaker-threshold", 5,
+		"Consecutive failed requests which suspend calls to the quote provider. Use 0 to disable",
+	)
+
+	flag.DurationVar(
+		&breakerCooldown, "breaker-cooldown", 30*time.Second,
+		"Time calls to the quote provider stay suspended before a new attempt",
+	)
+
+	flag.StringVar(
+		&aliasesFile, "aliases", "",
+		"JSON or YAML file mapping symbol aliases to the symbols used by the quote provider. Defaults to SYMBOL_ALIASES_FILE environment variable",
+	)
+
+	flag.StringVar(
+		&symbolPattern, "symbol-pattern", exchange.DefaultSymbolPattern,
+		"Regular expression every requested symbol must match before being sent to the quote provider",
+	)
+
+	flag.IntVar(
+		&chunkSize, "chunk-size", 0,
+		"Maximum symbols sent to the quote provider on a single call. Defaults to the provider's own limit",
+	)
+
+	flag.IntVar(
+		&chunkWorkers, "chunk-workers", exchange.DefaultChunkWorkers,
+		"Maximum chunks of symbols fetched concurrently",
+	)
+
+	flag.DurationVar(
+		&cacheTTL, "cache-ttl", 0,
+		"Time each quote is kept in memory and served to following requests without calling the quote provider. Use 0 to disable",
+	)
+
+	flag.StringVar(
+		&recordDir, "record", "",
+		"Saves every raw response received from the quote provider on the given directory",
+	)
+
+	flag.StringVar(
+		&replayDir, "replay", "",
+		"Replays raw responses recorded with -record from the given directory, instead of calling the quote provider",
+	)
+
+	flag.StringVar(
+		&searchText, "search", "",
+		"Searches the symbol directory for symbols or company names matching the given text",
+	)
+
+	flag.BoolVar(
+		&strictSymbols, "strict", false,
+		"Rejects symbols missing from the symbol directory without calling the quote provider",
+	)
+
+	flag.BoolVar(
+		&legacyRequests, "legacy-requests", true,
+		"Accepts MQ requests without the versioned envelope, answering them on the legacy results shape. Will be removed on the next release",
+	)
+
+	flag.StringVar(
+		&directoryFile, "directory", "",
+		"CSV file with symbol, name, exchange, currency and type columns used as symbol directory. Defaults to SYMBOL_DIRECTORY_FILE environment variable or the embedded directory",
+	)
+
 	flag.Var(
 		&symbols, "indices",
 		"List of comma-separated symbols.\n\tExample:\n\t\t-indices=AAPL\n\t\t-indices AAPL\n\t\t-indices='AAPL, GOOGL'\n\t\t-indices 'AAPL, GOOGL'",
@@ -41,6 +209,8 @@ func parseCommandFlags() {
 
 func runProcessOnMQ() {
 	loadEnvironment()
+	selectProvider()
+	loadDirectory()
 
 	fmt.Println("Connecting to AMQP server...")
 	connection, err := connector.OpenConnection()
@@ -68,43 +238,342 @@ func runProcessOnMQ() {
 
 	fmt.Printf("\n\nWaiting for indices. Press Crtl+C to exit.\n\n")
 
-	openConnectionToApplication := make(chan bool)
-	indicesReceived := make(chan []string)
+	ctx, cancel := interruptibleContext()
+	defer cancel()
 
-	go connector.HandleReceivedIndices(subscriber, indicesReceived)
+	requestsReceived := make(chan connector.Request)
 
-	for indices := range indicesReceived {
-		result := requestIndices(indices)
-		err = connector.PublishIndices(channel, queueForPublishing.Name, result)
-		logOperationResult(err, "Published results to subscribers.")
-	}
+	go connector.HandleReceivedRequests(subscriber, requestsReceived)
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Closing connection to AMQP server...")
+			return
+		case request := <-requestsReceived:
+			if request.Enveloped && request.RequestedAt == "" {
+				request.RequestedAt = time.Now().UTC().Format(time.RFC3339)
+			}
+
+			if requestErr := request.Validate(legacyRequests); requestErr != nil {
+				err = publishRequestError(channel, queueForPublishing.Name, request, requestErr, resultsOptions())
+			} else if request.Search != "" {
+				err = publishSearchResults(channel, queueForPublishing.Name, request)
+			} else {
+				err = publishIndicesResults(ctx, channel, queueForPublishing.Name, request)
+			}
 
-	<-openConnectionToApplication
+			logOperationResult(err, "Published results to subscribers.")
+		}
+	}
 }
 
 func logIndicesRequest() {
-	result, err := indices.Join(requestIndices(symbols).Exchanges)
+	loadOptionalEnvironment()
+	selectProvider()
+
+	if strictSymbols {
+		loadDirectory()
+	}
+
+	ctx, cancel := interruptibleContext()
+	defer cancel()
+
+	result, err := indices.Join(requestIndices(ctx, symbols), resultsOptions())
 	logOperationResult(err, fmt.Sprintf("%s", result))
 }
 
-func requestIndices(indices []string) *exchange.ExchangesResult {
-	fmt.Printf("Indices received are: %v\n", indices)
+func publishIndicesResults(ctx context.Context, channel *amqp.Channel, queueName string, request connector.Request) error {
+	options := resultsOptions()
 
-	url := exchange.BuildURL(indices)
+	if len(request.Fields) > 0 {
+		options.Fields = request.Fields
+	}
 
-	result, err := exchange.Fetch(url)
-	logOperationResult(
-		err, "Setting connection and fetching results from Yahoo! API...",
-	)
+	if requestErr := options.Validate(); requestErr != nil {
+		return publishRequestError(channel, queueName, request, requestErr, options)
+	}
+
+	result := requestIndices(ctx, request.Indices)
+
+	var response []byte
+	var err error
+
+	if request.Enveloped {
+		response, err = indices.JoinEnvelope(request.Request, result, options)
+	} else {
+		response, err = indices.Join(result, options)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return request.Reply(channel, queueName, response)
+}
+
+func publishRequestError(channel *amqp.Channel, queueName string, request connector.Request, requestErr error, options indices.Options) error {
+	log.Println(requestErr)
+
+	var response []byte
+	var err error
+
+	if request.Enveloped || !legacyRequests {
+		response, err = indices.JoinErrorEnvelope(request.Request, requestErr)
+	} else {
+		response, err = indices.JoinError(requestErr, options)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return request.Reply(channel, queueName, response)
+}
+
+func publishSearchResults(channel *amqp.Channel, queueName string, request connector.Request) error {
+	fmt.Printf("Search received is: %s\n", request.Search)
+
+	listings := directory.Search(request.Search, request.Limit)
+	var response []byte
+	var err error
+
+	if request.Enveloped {
+		response, err = indices.JoinSearchEnvelope(request.Request, listings)
+	} else {
+		response, err = indices.JoinSearch(request.Search, listings)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return request.Reply(channel, queueName, response)
+}
+
+func logSearchResults() {
+	loadOptionalEnvironment()
+	loadDirectory()
+
+	listings := directory.Search(searchText, indices.DefaultSearchLimit)
+
+	if len(listings) == 0 {
+		log.Printf("No symbols found for '%s'.\n", searchText)
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "SYMBOL\tNAME\tEXCHANGE\tCURRENCY\tTYPE")
+
+	for _, listing := range listings {
+		fmt.Fprintf(
+			writer, "%s\t%s\t%s\t%s\t%s\n",
+			listing.Symbol, listing.Name, listing.Exchange, listing.Currency, listing.Type,
+		)
+	}
+
+	writer.Flush()
+}
 
-	err = result.Parse()
+func requestIndices(ctx context.Context, requested []string) *exchange.ExchangesResult {
+	fmt.Printf("Indices received are: %v\n", requested)
+
+	normalization := indices.Normalize(requested, aliases)
+	requestable, rejected := exchange.FilterSymbols(normalization.Symbols)
+	var unknown map[string]error
+
+	if strictSymbols {
+		requestable, unknown = directory.Validate(requestable)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	result, err := fetchQuotes(ctx, requestable)
 	logOperationResult(
-		err, "Successfully received results from Yahoo! API.",
+		err, fmt.Sprintf("Successfully received results from '%s' provider.", providerName),
 	)
 
+	if quoteCache != nil {
+		hits, misses := quoteCache.Stats()
+		log.Printf("Quote cache hits: %d, misses: %d\n", hits, misses)
+	}
+
+	if result == nil {
+		result = exchange.FailedResult(requestable, err)
+	}
+
+	for _, symbolErrs := range []map[string]error{rejected, unknown} {
+		for symbol, symbolErr := range symbolErrs {
+			if result.Errors == nil {
+				result.Errors = make(map[string]error)
+			}
+
+			result.Errors[symbol] = symbolErr
+		}
+	}
+
+	for symbol, symbolErr := range result.Errors {
+		log.Printf("Could not fetch '%s': %v\n", symbol, symbolErr)
+	}
+
+	result.Resolved = normalization.Resolved
+
 	return result
 }
 
+func fetchQuotes(ctx context.Context, symbols []string) (*exchange.ExchangesResult, error) {
+	if len(symbols) == 0 {
+		return &exchange.ExchangesResult{Exchanges: make(map[string]exchange.Exchange)}, nil
+	}
+
+	return quoteProvider.Quotes(ctx, symbols)
+}
+
+func resultsOptions() indices.Options {
+	if schemaVersion == 0 {
+		schemaVersion, _ = strconv.Atoi(os.Getenv("RESULTS_SCHEMA_VERSION"))
+	}
+
+	if resultFields == "" {
+		resultFields = os.Getenv("RESULTS_FIELDS")
+	}
+
+	return indices.Options{Version: schemaVersion, Fields: indices.SplitListBody(resultFields)}
+}
+
+func selectProvider() {
+	logFailureAndCrash(resultsOptions().Validate())
+
+	if providerName == "" {
+		providerName = os.Getenv("QUOTE_PROVIDER")
+	}
+
+	if providerName == "" {
+		providerName = exchange.DefaultProvider
+	}
+
+	loadRetryPolicy()
+
+	for _, code := range indices.SplitListBody(retryStatusCodes) {
+		statusCode, err := strconv.Atoi(code)
+		logFailureAndCrash(err)
+		retryPolicy.RetryableStatusCodes = append(retryPolicy.RetryableStatusCodes, statusCode)
+	}
+
+	logFailureAndCrash(exchange.SetSymbolPattern(symbolPattern))
+	loadAliases()
+
+	client := exchange.NewHTTPClient(connectTimeout, readTimeout, retryPolicy)
+	provider, err := exchange.NewProvider(providerName, client)
+	logFailureAndCrash(err)
+
+	if sizer, ok := provider.(exchange.ChunkSizer); ok && chunkSize == 0 {
+		chunkSize = sizer.ChunkSize()
+	}
+
+	switch {
+	case recordDir != "" && replayDir != "":
+		log.Fatal("Flags -record and -replay cannot be used together.")
+	case recordDir != "":
+		provider = &exchange.RecordingProvider{Provider: provider, Dir: recordDir}
+	case replayDir != "":
+		decoder, ok := provider.(exchange.Decoder)
+
+		if !ok {
+			log.Fatalf("Provider '%s' does not support replaying recorded responses.", providerName)
+		}
+
+		provider = &exchange.ReplayProvider{Dir: replayDir, Decoder: decoder}
+	}
+
+	if chunkSize > 0 {
+		provider = &exchange.ChunkedProvider{Provider: provider, Size: chunkSize, Workers: chunkWorkers}
+	}
+
+	if breakerThreshold > 0 {
+		provider = &exchange.CircuitBreaker{
+			Provider:         provider,
+			FailureThreshold: breakerThreshold,
+			Cooldown:         breakerCooldown,
+		}
+	}
+
+	provider = &exchange.Coalescer{Provider: provider}
+
+	if cacheTTL > 0 {
+		quoteCache = &exchange.Cache{Provider: provider, TTL: cacheTTL}
+		provider = quoteCache
+	}
+
+	quoteProvider = provider
+}
+
+func loadRetryPolicy() {
+	explicit := make(map[string]bool)
+	flag.Visit(func(option *flag.Flag) { explicit[option.Name] = true })
+
+	for name, variable := range retryEnvironment {
+		value := os.Getenv(variable)
+
+		if value == "" || explicit[name] {
+			continue
+		}
+
+		if err := flag.Set(name, value); err != nil {
+			logFailureAndCrash(fmt.Errorf("Environment variable %s has an invalid value '%s': %v", variable, value, err))
+		}
+	}
+}
+
+func loadAliases() {
+	if aliasesFile == "" {
+		aliasesFile = os.Getenv("SYMBOL_ALIASES_FILE")
+	}
+
+	if aliasesFile == "" {
+		return
+	}
+
+	var err error
+	aliases, err = indices.LoadAliases(aliasesFile)
+	logFailureAndCrash(err)
+}
+
+func loadDirectory() {
+	if directoryFile == "" {
+		directoryFile = os.Getenv("SYMBOL_DIRECTORY_FILE")
+	}
+
+	if directoryFile == "" {
+		directory = indices.DefaultDirectory()
+		return
+	}
+
+	var err error
+	directory, err = indices.LoadDirectory(directoryFile)
+	logFailureAndCrash(err)
+}
+
+func interruptibleContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interruptions := make(chan os.Signal, 1)
+	signal.Notify(interruptions, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(interruptions)
+
+		select {
+		case <-interruptions:
+			log.Println("Interrupted. Cancelling in-flight requests...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
 func logOperationResult(err error, message string) {
 	if err == nil {
 		log.Println(message)
@@ -119,6 +588,12 @@ func loadEnvironment() {
 	}
 }
 
+func loadOptionalEnvironment() {
+	if _, err := os.Stat(".env"); err == nil {
+		loadEnvironment()
+	}
+}
+
 func logFailureAndCrash(err error) {
 	if err != nil {
 		log.Fatal(err)
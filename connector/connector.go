@@ -16,6 +16,11 @@ type ChannelError struct {
 	err error
 }
 
+type Request struct {
+	indices.Request
+	ReplyTo, CorrelationID string
+}
+
 func OpenConnection() (*amqp.Connection, error) {
 	connection, err := amqp.Dial(formatAmqpURL())
 
@@ -73,21 +78,48 @@ func HandleReceivedIndices(subscriber <-chan amqp.Delivery, indicesChannel chan
 	}
 }
 
-func PublishIndices(channel *amqp.Channel, queueName string, result *exchange.ExchangesResult) error {
-	response, err := indices.Join(result.Exchanges)
+func HandleReceivedRequests(subscriber <-chan amqp.Delivery, requestsChannel chan Request) {
+	for delivery := range subscriber {
+		requestsChannel <- Request{
+			Request:       indices.ParseRequest(delivery.Body),
+			ReplyTo:       delivery.ReplyTo,
+			CorrelationID: delivery.CorrelationId,
+		}
+	}
+}
+
+func PublishIndices(channel *amqp.Channel, queueName string, result *exchange.ExchangesResult, options indices.Options) error {
+	response, err := indices.Join(result, options)
 
 	if err != nil {
 		return err
 	}
 
+	return Publish(channel, queueName, response)
+}
+
+func Publish(channel *amqp.Channel, queueName string, response []byte) error {
+	return publish(channel, queueName, "", response)
+}
+
+func (request Request) Reply(channel *amqp.Channel, queueName string, response []byte) error {
+	if request.ReplyTo != "" {
+		queueName = request.ReplyTo
+	}
+
+	return publish(channel, queueName, request.CorrelationID, response)
+}
+
+func publish(channel *amqp.Channel, queueName, correlationID string, response []byte) error {
 	if publishingError := channel.Publish(
 		"",
 		queueName,
 		false,
 		false,
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        response,
+			ContentType:   "application/json",
+			CorrelationId: correlationID,
+			Body:          response,
 		},
 	); publishingError != nil {
 		return publishingError
@@ -5,6 +5,7 @@ package connector
 import (
 	"fmt"
 	"github.com/docStonehenge/exchange_fetcher/exchange"
+	"github.com/docStonehenge/exchange_fetcher/indices"
 	"github.com/streadway/amqp"
 	"os"
 	"regexp"
@@ -348,24 +349,138 @@ func TestHandleReceivedIndicesSkipsEmptyStringBodyReceived(t *testing.T) {
 	)
 }
 
+func TestHandleReceivedRequestsPutsSearchOnChannel(t *testing.T) {
+	integrationEnvironmentForTest(
+		t,
+		func(channel *amqp.Channel, queueName string) {
+			testBody := "{\"search\": \"apple\", \"limit\": 3}"
+			channel.Publish(
+				"",
+				queueName,
+				false,
+				false,
+				amqp.Publishing{
+					ContentType: "application/json",
+					Body:        []byte(testBody),
+				},
+			)
+
+			subscriber, err := channel.Consume(
+				queueName, "", true, false, false, false, nil,
+			)
+
+			if err != nil {
+				t.Fatal()
+			}
+
+			requestsChannel := make(chan Request)
+
+			go HandleReceivedRequests(subscriber, requestsChannel)
+			request := <-requestsChannel
+
+			if request.Search != "apple" || request.Limit != 3 || len(request.Indices) != 0 {
+				t.Fatalf("Should handle subscriber received search request, but received %v", request)
+			}
+		},
+	)
+}
+
 func TestPublishIndices(t *testing.T) {
 	integrationEnvironmentForTest(
 		t,
 		func(channel *amqp.Channel, queueName string) {
 			result := &exchange.ExchangesResult{
 				Exchanges: map[string]exchange.Exchange{
-					"Nikkei 225":    exchange.Exchange{Name: "Nikkei 225", Symbol: "^n225", PercentChange: "-0.91%", ChangeInPoints: "-172.98", Price: 78000.0000, PreviousClose: 70000.0000, OpenPrice: 76592.1150, LastTradeDate: "4/14/2017", LastTradeTime: "3:15pm"},
-					"Alphabet Inc.": exchange.Exchange{Name: "Alphabet Inc.", Symbol: "GOOGL", PercentChange: "-0.09%", ChangeInPoints: "-0.76", Price: 78000.0000, PreviousClose: 70000.0000, OpenPrice: 76592.1150, LastTradeDate: "4/13/2017", LastTradeTime: "4:00pm"},
+					"^n225": exchange.Exchange{Name: "Nikkei 225", Symbol: "^n225", PercentChange: exchange.MustParseDecimal("-0.91"), ChangeInPoints: exchange.MustParseDecimal("-172.98"), Price: exchange.MustParseDecimal("78000.0000"), PreviousClose: exchange.MustParseDecimal("70000.0000"), OpenPrice: exchange.MustParseDecimal("76592.1150"), LastTradeDate: "4/14/2017", LastTradeTime: "3:15pm"},
+					"GOOGL": exchange.Exchange{Name: "Alphabet Inc.", Symbol: "GOOGL", PercentChange: exchange.MustParseDecimal("-0.09"), ChangeInPoints: exchange.MustParseDecimal("-0.76"), Price: exchange.MustParseDecimal("78000.0000"), PreviousClose: exchange.MustParseDecimal("70000.0000"), OpenPrice: exchange.MustParseDecimal("76592.1150"), LastTradeDate: "4/13/2017", LastTradeTime: "4:00pm"},
+				},
+			}
+
+			PublishIndices(channel, queueName, result, indices.Options{Version: indices.NameKeyedVersion})
+
+			msgs, _ := channel.Consume(
+				queueName, "", true, false, false, false, nil,
+			)
+
+			expected := "{\"Alphabet Inc.\":{\"Name\":\"Alphabet Inc.\",\"Symbol\":\"GOOGL\",\"Price\":78000.0000,\"PreviousClose\":70000.0000,\"OpenPrice\":76592.1150,\"PercentChange\":\"-0.09%\",\"ChangeInPoints\":\"-0.76\",\"LastTradeDate\":\"4/13/2017\",\"LastTradeTime\":\"4:00pm\"},\"Nikkei 225\":{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"Price\":78000.0000,\"PreviousClose\":70000.0000,\"OpenPrice\":76592.1150,\"PercentChange\":\"-0.91%\",\"ChangeInPoints\":\"-172.98\",\"LastTradeDate\":\"4/14/2017\",\"LastTradeTime\":\"3:15pm\"}}"
+
+			msg := <-msgs
+			parsedBody := string(msg.Body)
+
+			if parsedBody != expected {
+				t.Fatalf("Published body should be %s, but it is %s", expected, parsedBody)
+			}
+		},
+	)
+}
+
+func TestReplyPublishesOnReplyToQueueWithCorrelationID(t *testing.T) {
+	integrationEnvironmentForTest(
+		t,
+		func(channel *amqp.Channel, queueName string) {
+			request := Request{ReplyTo: queueName, CorrelationID: "abc-1"}
+
+			if err := request.Reply(channel, "exchange_fetcher.indices.unused", []byte("{}")); err != nil {
+				t.Fatalf("Reply should publish response, but returned error: %v", err)
+			}
+
+			msgs, _ := channel.Consume(
+				queueName, "", true, false, false, false, nil,
+			)
+
+			msg := <-msgs
+
+			if msg.CorrelationId != "abc-1" || string(msg.Body) != "{}" {
+				t.Fatalf("Reply should be published with correlation id abc-1, but received %s with %s", msg.Body, msg.CorrelationId)
+			}
+		},
+	)
+}
+
+func TestPublishIndicesWithSymbolKeyedVersion(t *testing.T) {
+	integrationEnvironmentForTest(
+		t,
+		func(channel *amqp.Channel, queueName string) {
+			result := &exchange.ExchangesResult{
+				Exchanges: map[string]exchange.Exchange{
+					"GOOGL": exchange.Exchange{Name: "Alphabet Inc.", Symbol: "GOOGL", PercentChange: exchange.MustParseDecimal("-0.09"), ChangeInPoints: exchange.MustParseDecimal("-0.76"), Price: exchange.MustParseDecimal("78000.0000"), PreviousClose: exchange.MustParseDecimal("70000.0000"), OpenPrice: exchange.MustParseDecimal("76592.1150"), LastTradeDate: "4/13/2017", LastTradeTime: "4:00pm"},
 				},
 			}
 
-			PublishIndices(channel, queueName, result)
+			PublishIndices(channel, queueName, result, indices.Options{Version: indices.SymbolKeyedVersion})
+
+			msgs, _ := channel.Consume(
+				queueName, "", true, false, false, false, nil,
+			)
+
+			expected := "{\"version\":2,\"exchanges\":{\"GOOGL\":{\"Name\":\"Alphabet Inc.\",\"Symbol\":\"GOOGL\",\"Price\":78000.0000,\"PreviousClose\":70000.0000,\"OpenPrice\":76592.1150,\"PercentChange\":\"-0.09%\",\"ChangeInPoints\":\"-0.76\",\"LastTradeDate\":\"4/13/2017\",\"LastTradeTime\":\"4:00pm\"}}}"
+
+			msg := <-msgs
+			parsedBody := string(msg.Body)
+
+			if parsedBody != expected {
+				t.Fatalf("Published body should be %s, but it is %s", expected, parsedBody)
+			}
+		},
+	)
+}
+
+func TestPublishIndicesIncludesErrorsSection(t *testing.T) {
+	integrationEnvironmentForTest(
+		t,
+		func(channel *amqp.Channel, queueName string) {
+			result := &exchange.ExchangesResult{
+				Exchanges: map[string]exchange.Exchange{},
+				Errors:    map[string]error{"FOO": &exchange.MissingSymbolError{Symbol: "FOO"}},
+			}
+
+			PublishIndices(channel, queueName, result, indices.Options{Version: indices.SymbolKeyedVersion})
 
 			msgs, _ := channel.Consume(
 				queueName, "", true, false, false, false, nil,
 			)
 
-			expected := "{\"Alphabet Inc.\":{\"Name\":\"Alphabet Inc.\",\"Symbol\":\"GOOGL\",\"Price\":78000,\"PreviousClose\":70000,\"OpenPrice\":76592.115,\"PercentChange\":\"-0.09%\",\"ChangeInPoints\":\"-0.76\",\"LastTradeDate\":\"4/13/2017\",\"LastTradeTime\":\"4:00pm\"},\"Nikkei 225\":{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"Price\":78000,\"PreviousClose\":70000,\"OpenPrice\":76592.115,\"PercentChange\":\"-0.91%\",\"ChangeInPoints\":\"-172.98\",\"LastTradeDate\":\"4/14/2017\",\"LastTradeTime\":\"3:15pm\"}}"
+			expected := "{\"version\":2,\"exchanges\":{},\"errors\":{\"FOO\":\"No quote was returned for 'FOO'.\"}}"
 
 			msg := <-msgs
 			parsedBody := string(msg.Body)
@@ -383,13 +498,13 @@ func TestPublishIndicesRaisesErrorOnPublishProblem(t *testing.T) {
 		func(channel *amqp.Channel, queueName string) {
 			result := &exchange.ExchangesResult{
 				Exchanges: map[string]exchange.Exchange{
-					"Nikkei 225":    exchange.Exchange{Name: "Nikkei 225", Symbol: "^n225", PercentChange: "-0.91%", ChangeInPoints: "-172.98", Price: 78000.0000, PreviousClose: 70000.0000, OpenPrice: 76592.1150, LastTradeDate: "4/14/2017", LastTradeTime: "3:15pm"},
-					"Alphabet Inc.": exchange.Exchange{Name: "Alphabet Inc.", Symbol: "GOOGL", PercentChange: "-0.09%", ChangeInPoints: "-0.76", Price: 78000.0000, PreviousClose: 70000.0000, OpenPrice: 76592.1150, LastTradeDate: "4/13/2017", LastTradeTime: "4:00pm"},
+					"^n225": exchange.Exchange{Name: "Nikkei 225", Symbol: "^n225", PercentChange: exchange.MustParseDecimal("-0.91"), ChangeInPoints: exchange.MustParseDecimal("-172.98"), Price: exchange.MustParseDecimal("78000.0000"), PreviousClose: exchange.MustParseDecimal("70000.0000"), OpenPrice: exchange.MustParseDecimal("76592.1150"), LastTradeDate: "4/14/2017", LastTradeTime: "3:15pm"},
+					"GOOGL": exchange.Exchange{Name: "Alphabet Inc.", Symbol: "GOOGL", PercentChange: exchange.MustParseDecimal("-0.09"), ChangeInPoints: exchange.MustParseDecimal("-0.76"), Price: exchange.MustParseDecimal("78000.0000"), PreviousClose: exchange.MustParseDecimal("70000.0000"), OpenPrice: exchange.MustParseDecimal("76592.1150"), LastTradeDate: "4/13/2017", LastTradeTime: "4:00pm"},
 				},
 			}
 
 			channel.Close()
-			publishError := PublishIndices(channel, queueName, result)
+			publishError := PublishIndices(channel, queueName, result, indices.Options{Version: indices.NameKeyedVersion})
 
 			if publishError == nil {
 				t.Fatal("PublishIndices should run with errors, but no error was raised")
@@ -0,0 +1,133 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+type BreakerState int
+
+type CircuitBreaker struct {
+	Provider         Provider
+	FailureThreshold int
+	Cooldown         time.Duration
+	now              func() time.Time
+	mutex            sync.Mutex
+	state            BreakerState
+	failures         int
+	openedAt         time.Time
+	probing          bool
+}
+
+type ProviderUnavailableError struct {
+	RetryAt time.Time
+}
+
+func (breaker *CircuitBreaker) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	if err := breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	result, err := breaker.Provider.Quotes(ctx, symbols)
+	breaker.record(err, clientCaused(ctx, err))
+
+	return result, err
+}
+
+func (breaker *CircuitBreaker) State() BreakerState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	return breaker.state
+}
+
+func (breaker *CircuitBreaker) allow() error {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	retryAt := breaker.openedAt.Add(breaker.Cooldown)
+
+	switch breaker.state {
+	case BreakerOpen:
+		if breaker.clock().Before(retryAt) {
+			return &ProviderUnavailableError{RetryAt: retryAt}
+		}
+
+		breaker.state = BreakerHalfOpen
+		breaker.probing = true
+	case BreakerHalfOpen:
+		if breaker.probing {
+			return &ProviderUnavailableError{RetryAt: retryAt}
+		}
+
+		breaker.probing = true
+	}
+
+	return nil
+}
+
+func (breaker *CircuitBreaker) record(err error, ignored bool) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.probing = false
+
+	if ignored {
+		return
+	}
+
+	if err == nil {
+		breaker.state = BreakerClosed
+		breaker.failures = 0
+		return
+	}
+
+	breaker.failures++
+
+	if breaker.state == BreakerHalfOpen || breaker.failures >= breaker.FailureThreshold {
+		breaker.state = BreakerOpen
+		breaker.openedAt = breaker.clock()
+	}
+}
+
+func clientCaused(ctx context.Context, err error) bool {
+	if _, ok := err.(*InvalidSymbolError); ok {
+		return true
+	}
+
+	return err == context.Canceled || ctx.Err() == context.Canceled
+}
+
+func (breaker *CircuitBreaker) clock() time.Time {
+	if breaker.now == nil {
+		return time.Now()
+	}
+
+	return breaker.now()
+}
+
+func (state BreakerState) String() string {
+	switch state {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+
+	return "closed"
+}
+
+func (err *ProviderUnavailableError) Error() string {
+	return fmt.Sprintf(
+		"Quote provider is unavailable after repeated failures. Requests are suspended until %s.",
+		err.RetryAt.Format(time.RFC3339),
+	)
+}
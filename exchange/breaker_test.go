@@ -0,0 +1,155 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type scriptedProvider struct {
+	errors []error
+	calls  int
+}
+
+func (provider *scriptedProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	var err error
+
+	if provider.calls < len(provider.errors) {
+		err = provider.errors[provider.calls]
+	}
+
+	provider.calls++
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExchangesResult{Exchanges: make(map[string]Exchange)}, nil
+}
+
+type fakeClock struct {
+	current time.Time
+}
+
+func (clock *fakeClock) now() time.Time {
+	return clock.current
+}
+
+func (clock *fakeClock) advance(duration time.Duration) {
+	clock.current = clock.current.Add(duration)
+}
+
+func TestCircuitBreakerOpensAfterFailureThreshold(t *testing.T) {
+	upstreamErr := errors.New("connection refused")
+	provider := &scriptedProvider{errors: []error{upstreamErr, upstreamErr, upstreamErr}}
+	clock := &fakeClock{current: time.Date(2017, 10, 5, 16, 0, 0, 0, time.UTC)}
+	breaker := &CircuitBreaker{Provider: provider, FailureThreshold: 2, Cooldown: time.Minute, now: clock.now}
+
+	breaker.Quotes(context.Background(), []string{"AAPL"})
+
+	if state := breaker.State(); state != BreakerClosed {
+		t.Fatalf("Breaker should stay %v below threshold, but is %v", BreakerClosed, state)
+	}
+
+	breaker.Quotes(context.Background(), []string{"AAPL"})
+
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("Breaker should be %v after reaching threshold, but is %v", BreakerOpen, state)
+	}
+
+	_, err := breaker.Quotes(context.Background(), []string{"AAPL"})
+
+	unavailableErr, ok := err.(*ProviderUnavailableError)
+
+	if !ok {
+		t.Fatalf("Open breaker should return ProviderUnavailableError, but returned %v", err)
+	}
+
+	if !unavailableErr.RetryAt.Equal(clock.current.Add(time.Minute)) {
+		t.Fatalf("ProviderUnavailableError should retry at %v, but retries at %v", clock.current.Add(time.Minute), unavailableErr.RetryAt)
+	}
+
+	if provider.calls != 2 {
+		t.Fatalf("Open breaker should not call provider, but provider was called %d times", provider.calls)
+	}
+}
+
+func TestCircuitBreakerClosesAfterSuccessfulProbe(t *testing.T) {
+	upstreamErr := errors.New("connection refused")
+	provider := &scriptedProvider{errors: []error{upstreamErr}}
+	clock := &fakeClock{current: time.Date(2017, 10, 5, 16, 0, 0, 0, time.UTC)}
+	breaker := &CircuitBreaker{Provider: provider, FailureThreshold: 1, Cooldown: time.Minute, now: clock.now}
+
+	breaker.Quotes(context.Background(), []string{"AAPL"})
+	clock.advance(time.Minute)
+
+	if _, err := breaker.Quotes(context.Background(), []string{"AAPL"}); err != nil {
+		t.Fatalf("Breaker should let probe through after cool-down, but returned %v", err)
+	}
+
+	if state := breaker.State(); state != BreakerClosed {
+		t.Fatalf("Breaker should be %v after successful probe, but is %v", BreakerClosed, state)
+	}
+}
+
+func TestCircuitBreakerReopensAfterFailedProbe(t *testing.T) {
+	upstreamErr := errors.New("connection refused")
+	provider := &scriptedProvider{errors: []error{upstreamErr, upstreamErr}}
+	clock := &fakeClock{current: time.Date(2017, 10, 5, 16, 0, 0, 0, time.UTC)}
+	breaker := &CircuitBreaker{Provider: provider, FailureThreshold: 1, Cooldown: time.Minute, now: clock.now}
+
+	breaker.Quotes(context.Background(), []string{"AAPL"})
+	clock.advance(2 * time.Minute)
+	breaker.Quotes(context.Background(), []string{"AAPL"})
+
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("Breaker should be %v after failed probe, but is %v", BreakerOpen, state)
+	}
+
+	if _, err := breaker.Quotes(context.Background(), []string{"AAPL"}); err == nil {
+		t.Fatal("Reopened breaker should short-circuit requests, but nothing happened.")
+	}
+}
+
+func TestCircuitBreakerAllowsSingleProbeWhenHalfOpen(t *testing.T) {
+	breaker := &CircuitBreaker{Provider: &scriptedProvider{}, FailureThreshold: 1, Cooldown: time.Minute}
+	breaker.state = BreakerHalfOpen
+
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("Half-open breaker should allow a probe, but returned %v", err)
+	}
+
+	if err := breaker.allow(); err == nil {
+		t.Fatal("Half-open breaker should not allow a second probe while the first is in flight")
+	}
+}
+
+func TestCircuitBreakerIgnoresCancelledRequests(t *testing.T) {
+	provider := &scriptedProvider{errors: []error{context.Canceled}}
+	breaker := &CircuitBreaker{Provider: provider, FailureThreshold: 1, Cooldown: time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	breaker.Quotes(ctx, []string{"AAPL"})
+
+	if state := breaker.State(); state != BreakerClosed {
+		t.Fatalf("Cancelled requests should not open breaker, but it is %v", state)
+	}
+}
+
+func TestCircuitBreakerIgnoresInvalidSymbols(t *testing.T) {
+	provider := &YQLProvider{}
+	breaker := &CircuitBreaker{Provider: provider, FailureThreshold: 1, Cooldown: time.Minute}
+
+	for i := 0; i < 5; i++ {
+		if _, err := breaker.Quotes(context.Background(), []string{"bad symbol!"}); err == nil {
+			t.Fatalf("Invalid symbols should return error, but none was returned")
+		}
+	}
+
+	if state := breaker.State(); state != BreakerClosed {
+		t.Fatalf("Invalid symbols should not open breaker, but it is %v", state)
+	}
+}
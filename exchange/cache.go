@@ -0,0 +1,114 @@
+package exchange
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Cache struct {
+	Provider     Provider
+	TTL          time.Duration
+	now          func() time.Time
+	mutex        sync.Mutex
+	entries      map[string]cacheEntry
+	hits, misses uint64
+}
+
+type cacheEntry struct {
+	exchange  Exchange
+	expiresAt time.Time
+}
+
+func (cache *Cache) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	result := &ExchangesResult{Exchanges: make(map[string]Exchange)}
+	missing := cache.collectHits(symbols, result)
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	fetched, err := cache.Provider.Quotes(ctx, missing)
+
+	if fetched == nil {
+		for _, symbol := range missing {
+			result.setError(symbol, err)
+		}
+
+		return result, err
+	}
+
+	if len(missing) == len(symbols) {
+		result.rawResult = fetched.rawResult
+	}
+
+	cache.store(missing, fetched, result)
+
+	return result, err
+}
+
+func (cache *Cache) Stats() (hits, misses uint64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.hits, cache.misses
+}
+
+func (cache *Cache) collectHits(symbols []string, result *ExchangesResult) []string {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	var missing []string
+	now := cache.clock()
+
+	for _, symbol := range symbols {
+		key := strings.ToUpper(symbol)
+		entry, ok := cache.entries[key]
+
+		if ok && now.Before(entry.expiresAt) {
+			result.Exchanges[symbol] = entry.exchange
+			cache.hits++
+			continue
+		}
+
+		if ok {
+			delete(cache.entries, key)
+		}
+
+		missing = append(missing, symbol)
+		cache.misses++
+	}
+
+	return missing
+}
+
+func (cache *Cache) store(symbols []string, fetched, result *ExchangesResult) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.entries == nil {
+		cache.entries = make(map[string]cacheEntry)
+	}
+
+	expiresAt := cache.clock().Add(cache.TTL)
+
+	for _, symbol := range symbols {
+		if exchange, ok := fetched.Lookup(symbol); ok {
+			cache.entries[strings.ToUpper(symbol)] = cacheEntry{exchange: exchange, expiresAt: expiresAt}
+			result.Exchanges[symbol] = exchange
+		}
+	}
+
+	for symbol, err := range fetched.Errors {
+		result.setError(symbol, err)
+	}
+}
+
+func (cache *Cache) clock() time.Time {
+	if cache.now == nil {
+		return time.Now()
+	}
+
+	return cache.now()
+}
@@ -0,0 +1,130 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingProvider struct {
+	requests [][]string
+	err      error
+}
+
+func (provider *recordingProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	provider.requests = append(provider.requests, symbols)
+
+	if provider.err != nil {
+		return nil, provider.err
+	}
+
+	result := &ExchangesResult{Exchanges: make(map[string]Exchange)}
+
+	for _, symbol := range symbols {
+		if symbol == "FOO" {
+			result.setError(symbol, &MissingSymbolError{Symbol: symbol})
+			continue
+		}
+
+		result.Exchanges[symbol] = Exchange{Name: symbol + " Inc.", Symbol: symbol, Price: NewDecimal(int64(len(provider.requests)), 0)}
+	}
+
+	return result, nil
+}
+
+func TestCacheServesHitsAndFetchesOnlyMisses(t *testing.T) {
+	provider := &recordingProvider{}
+	clock := &fakeClock{current: time.Date(2017, 10, 5, 16, 0, 0, 0, time.UTC)}
+	cache := &Cache{Provider: provider, TTL: 10 * time.Second, now: clock.now}
+
+	cache.Quotes(context.Background(), []string{"AAPL"})
+	clock.advance(5 * time.Second)
+
+	result, err := cache.Quotes(context.Background(), []string{"aapl", "GOOGL"})
+
+	if err != nil {
+		t.Fatalf("Cache should return results, but returned error: %v", err)
+	}
+
+	if len(provider.requests) != 2 || strings.Join(provider.requests[1], ",") != "GOOGL" {
+		t.Fatalf("Cache should fetch only missing symbols, but requested %v", provider.requests)
+	}
+
+	if price := result.Exchanges["aapl"].Price.String(); price != "1" {
+		t.Fatalf("Cached exchange should come from first request, but price is %s", price)
+	}
+
+	if price := result.Exchanges["GOOGL"].Price.String(); price != "2" {
+		t.Fatalf("Missing exchange should come from second request, but price is %s", price)
+	}
+
+	if hits, misses := cache.Stats(); hits != 1 || misses != 2 {
+		t.Fatalf("Cache should count %d hits and %d misses, but counted %d and %d", 1, 2, hits, misses)
+	}
+}
+
+func TestCacheRefetchesExpiredEntries(t *testing.T) {
+	provider := &recordingProvider{}
+	clock := &fakeClock{current: time.Date(2017, 10, 5, 16, 0, 0, 0, time.UTC)}
+	cache := &Cache{Provider: provider, TTL: 10 * time.Second, now: clock.now}
+
+	cache.Quotes(context.Background(), []string{"AAPL"})
+	clock.advance(10 * time.Second)
+
+	result, _ := cache.Quotes(context.Background(), []string{"AAPL"})
+
+	if len(provider.requests) != 2 {
+		t.Fatalf("Expired entry should be fetched again, but provider was called %d times", len(provider.requests))
+	}
+
+	if price := result.Exchanges["AAPL"].Price.String(); price != "2" {
+		t.Fatalf("Expired entry should be replaced, but price is %s", price)
+	}
+
+	if hits, misses := cache.Stats(); hits != 0 || misses != 2 {
+		t.Fatalf("Cache should count %d hits and %d misses, but counted %d and %d", 0, 2, hits, misses)
+	}
+}
+
+func TestCacheDoesNotStoreSymbolErrors(t *testing.T) {
+	provider := &recordingProvider{}
+	cache := &Cache{Provider: provider, TTL: time.Minute}
+
+	result, _ := cache.Quotes(context.Background(), []string{"FOO"})
+
+	if _, ok := result.Errors["FOO"].(*MissingSymbolError); !ok {
+		t.Fatalf("Cache should merge symbol errors from provider, but errors are %v", result.Errors)
+	}
+
+	cache.Quotes(context.Background(), []string{"FOO"})
+
+	if len(provider.requests) != 2 {
+		t.Fatalf("Failed symbols should not be cached, but provider was called %d times", len(provider.requests))
+	}
+}
+
+func TestCacheKeepsHitsWhenProviderFails(t *testing.T) {
+	provider := &recordingProvider{}
+	cache := &Cache{Provider: provider, TTL: time.Minute}
+
+	cache.Quotes(context.Background(), []string{"AAPL"})
+
+	upstreamErr := errors.New("connection refused")
+	provider.err = upstreamErr
+
+	result, err := cache.Quotes(context.Background(), []string{"AAPL", "GOOGL"})
+
+	if err != upstreamErr {
+		t.Fatalf("Cache should return provider error, but returned %v", err)
+	}
+
+	if _, ok := result.Exchanges["AAPL"]; !ok {
+		t.Fatalf("Cache should keep cached exchanges, but has %v", result.Exchanges)
+	}
+
+	if result.Errors["GOOGL"] != upstreamErr {
+		t.Fatalf("Missing symbols should carry provider error, but errors are %v", result.Errors)
+	}
+}
@@ -0,0 +1,167 @@
+package exchange
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultMarketTimezone = "America/New_York"
+
+var MarketTimezones = map[string]string{
+	"NASDAQ":   "America/New_York",
+	"NMS":      "America/New_York",
+	"NGM":      "America/New_York",
+	"NCM":      "America/New_York",
+	"NYSE":     "America/New_York",
+	"NYQ":      "America/New_York",
+	"NYSEARCA": "America/New_York",
+	"PCX":      "America/New_York",
+	"SNP":      "America/New_York",
+	"DJI":      "America/New_York",
+	"TSX":      "America/Toronto",
+	"BOVESPA":  "America/Sao_Paulo",
+	"SAO":      "America/Sao_Paulo",
+	"LSE":      "Europe/London",
+	"FTSE":     "Europe/London",
+	"XETRA":    "Europe/Berlin",
+	"GER":      "Europe/Berlin",
+	"PAR":      "Europe/Paris",
+	"TYO":      "Asia/Tokyo",
+	"OSAKA":    "Asia/Tokyo",
+	"OSA":      "Asia/Tokyo",
+	"HKEX":     "Asia/Hong_Kong",
+	"HKG":      "Asia/Hong_Kong",
+	"ASX":      "Australia/Sydney",
+	"CCY":      "Europe/London",
+}
+
+var SymbolMarkets = map[string]string{
+	".SA":    "BOVESPA",
+	".TO":    "TSX",
+	".L":     "LSE",
+	".DE":    "XETRA",
+	".PA":    "PAR",
+	".T":     "TYO",
+	".HK":    "HKEX",
+	".AX":    "ASX",
+	"=X":     "CCY",
+	"^BVSP":  "BOVESPA",
+	"^FTSE":  "FTSE",
+	"^GDAXI": "XETRA",
+	"^N225":  "OSAKA",
+	"^HSI":   "HKEX",
+}
+
+var tradeDateLayouts = []string{"1/2/2006", "2006-01-02"}
+
+var tradeClockLayouts = []string{"3:04pm", "3:04PM", "15:04:05", "15:04"}
+
+var locations = struct {
+	sync.Mutex
+	cache map[string]*time.Location
+}{cache: make(map[string]*time.Location)}
+
+type TradeTime struct {
+	time.Time
+}
+
+func MarketLocation(symbol, market string) *time.Location {
+	timezone, ok := MarketTimezones[strings.ToUpper(market)]
+
+	if !ok {
+		timezone, ok = MarketTimezones[symbolMarket(symbol)]
+	}
+
+	if !ok {
+		if market != "" || strings.ContainsAny(symbol, ".=") {
+			return nil
+		}
+
+		timezone = DefaultMarketTimezone
+	}
+
+	return loadLocation(timezone)
+}
+
+func ParseTradeTime(date, clock string, location *time.Location) TradeTime {
+	if location == nil {
+		return TradeTime{}
+	}
+
+	day, ok := parseWithLayouts(tradeDateLayouts, strings.TrimSpace(date), location)
+
+	if !ok {
+		return TradeTime{}
+	}
+
+	moment, ok := parseWithLayouts(tradeClockLayouts, strings.TrimSpace(clock), time.UTC)
+
+	if !ok {
+		return TradeTime{}
+	}
+
+	return TradeTime{
+		time.Date(day.Year(), day.Month(), day.Day(), moment.Hour(), moment.Minute(), moment.Second(), 0, location),
+	}
+}
+
+func (tradeTime TradeTime) MarshalJSON() ([]byte, error) {
+	if tradeTime.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return tradeTime.Time.MarshalJSON()
+}
+
+func (exchange *Exchange) resolveLastTrade(market string) {
+	location := MarketLocation(exchange.Symbol, market)
+	exchange.LastTrade = ParseTradeTime(exchange.LastTradeDate, exchange.LastTradeTime, location)
+}
+
+func symbolMarket(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+
+	if market, ok := SymbolMarkets[symbol]; ok {
+		return market
+	}
+
+	var longest, market string
+
+	for suffix, suffixMarket := range SymbolMarkets {
+		if !strings.HasPrefix(suffix, "^") && strings.HasSuffix(symbol, suffix) && len(suffix) > len(longest) {
+			longest, market = suffix, suffixMarket
+		}
+	}
+
+	return market
+}
+
+func parseWithLayouts(layouts []string, value string, location *time.Location) (time.Time, bool) {
+	for _, layout := range layouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func loadLocation(timezone string) *time.Location {
+	locations.Lock()
+	defer locations.Unlock()
+
+	if location, ok := locations.cache[timezone]; ok {
+		return location
+	}
+
+	location, err := time.LoadLocation(timezone)
+
+	if err != nil {
+		location = nil
+	}
+
+	locations.cache[timezone] = location
+
+	return location
+}
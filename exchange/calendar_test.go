@@ -0,0 +1,75 @@
+package exchange
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func tradeTimeIn(timezone string, year int, month time.Month, day, hour, minute int) TradeTime {
+	return TradeTime{time.Date(year, month, day, hour, minute, 0, 0, loadLocation(timezone))}
+}
+
+func TestMarketLocation(t *testing.T) {
+	results := []struct {
+		symbol, market, timezone string
+	}{
+		{symbol: "AAPL", timezone: "America/New_York"},
+		{symbol: "MGLU3.SA", timezone: "America/Sao_Paulo"},
+		{symbol: "^n225", timezone: "Asia/Tokyo"},
+		{symbol: "EURUSD=X", timezone: "Europe/London"},
+		{symbol: "SHOP.TO", timezone: "America/Toronto"},
+		{symbol: "PETR4", market: "sao", timezone: "America/Sao_Paulo"},
+		{symbol: "7203.T", market: "JPX", timezone: "Asia/Tokyo"},
+		{symbol: "BMW.DE", market: "FRA", timezone: "Europe/Berlin"},
+		{symbol: "VOD.L", market: "LON", timezone: "Europe/London"},
+		{symbol: "FOO", timezone: DefaultMarketTimezone},
+		{symbol: "FOO", market: "UNKNOWN"},
+		{symbol: "ENI.MI", market: "MIL"},
+		{symbol: "ENI.MI"},
+	}
+
+	for _, r := range results {
+		location := MarketLocation(r.symbol, r.market)
+
+		if r.timezone == "" && location != nil {
+			t.Fatalf("Location of %s on '%s' should be unknown, but is %s", r.symbol, r.market, location)
+		}
+
+		if r.timezone != "" && (location == nil || location.String() != r.timezone) {
+			t.Fatalf("Location of %s on '%s' should be %s, but is %v", r.symbol, r.market, r.timezone, location)
+		}
+	}
+}
+
+func TestParseTradeTime(t *testing.T) {
+	saoPaulo := loadLocation("America/Sao_Paulo")
+
+	results := []struct {
+		date, clock string
+		exp         TradeTime
+	}{
+		{"10/5/2017", "4:00pm", tradeTimeIn("America/Sao_Paulo", 2017, time.October, 5, 16, 0)},
+		{"2017-10-05", "", TradeTime{}},
+		{"10/5/2017", "N/A", TradeTime{}},
+		{"2017-10-05", "09:30", tradeTimeIn("America/Sao_Paulo", 2017, time.October, 5, 9, 30)},
+		{"N/A", "4:00pm", TradeTime{}},
+		{"", "", TradeTime{}},
+	}
+
+	for _, r := range results {
+		if actual := ParseTradeTime(r.date, r.clock, saoPaulo); !actual.Equal(r.exp.Time) || actual.IsZero() != r.exp.IsZero() {
+			t.Fatalf("Trade time of '%s %s' should be %v, but is %v", r.date, r.clock, r.exp, actual)
+		}
+	}
+}
+
+func TestTradeTimeMarshalJSON(t *testing.T) {
+	body, _ := json.Marshal(
+		struct{ First, Missing TradeTime }{First: tradeTimeIn("Asia/Tokyo", 2017, time.April, 14, 15, 15)},
+	)
+
+	if string(body) != "{\"First\":\"2017-04-14T15:15:00+09:00\",\"Missing\":null}" {
+		t.Fatalf("Trade time should be marshaled as RFC 3339 and missing as null, but is %s", body)
+	}
+}
@@ -0,0 +1,127 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+)
+
+const DefaultChunkWorkers = 4
+
+type ChunkSizer interface {
+	ChunkSize() int
+}
+
+type ChunkedProvider struct {
+	Provider Provider
+	Size     int
+	Workers  int
+}
+
+type chunkResult struct {
+	symbols []string
+	result  *ExchangesResult
+	err     error
+}
+
+func (provider *ChunkedProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	chunks := provider.split(symbols)
+
+	if len(chunks) <= 1 {
+		return provider.Provider.Quotes(ctx, symbols)
+	}
+
+	results := make([]chunkResult, len(chunks))
+	workers := make(chan struct{}, provider.workers())
+	var group sync.WaitGroup
+
+	for i, chunk := range chunks {
+		group.Add(1)
+
+		go func(i int, chunk []string) {
+			defer group.Done()
+
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			result, err := provider.Provider.Quotes(ctx, chunk)
+			results[i] = chunkResult{symbols: chunk, result: result, err: err}
+		}(i, chunk)
+	}
+
+	group.Wait()
+
+	return merge(results)
+}
+
+func (provider *ChunkedProvider) split(symbols []string) [][]string {
+	if provider.Size <= 0 {
+		return [][]string{symbols}
+	}
+
+	var chunks [][]string
+
+	for start := 0; start < len(symbols); start += provider.Size {
+		end := start + provider.Size
+
+		if end > len(symbols) {
+			end = len(symbols)
+		}
+
+		chunks = append(chunks, symbols[start:end])
+	}
+
+	return chunks
+}
+
+func (provider *ChunkedProvider) workers() int {
+	if provider.Workers <= 0 {
+		return DefaultChunkWorkers
+	}
+
+	return provider.Workers
+}
+
+func merge(results []chunkResult) (*ExchangesResult, error) {
+	merged := &ExchangesResult{Exchanges: make(map[string]Exchange)}
+	var firstErr error
+	failures := 0
+
+	for _, chunk := range results {
+		if chunk.err != nil {
+			failures++
+
+			if firstErr == nil {
+				firstErr = chunk.err
+			}
+		}
+
+		if chunk.result != nil {
+			for symbol, exchange := range chunk.result.Exchanges {
+				merged.Exchanges[symbol] = exchange
+			}
+
+			for symbol, err := range chunk.result.Errors {
+				merged.setError(symbol, err)
+			}
+		}
+
+		if chunk.err == nil {
+			continue
+		}
+
+		for _, symbol := range chunk.symbols {
+			_, found := merged.Exchanges[symbol]
+			_, failed := merged.Errors[symbol]
+
+			if !found && !failed {
+				merged.setError(symbol, chunk.err)
+			}
+		}
+	}
+
+	if failures == len(results) {
+		return merged, firstErr
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,145 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type chunkRecorder struct {
+	mutex             sync.Mutex
+	requests          []string
+	inFlight, maximum int
+	release           chan struct{}
+	failing           map[string]error
+}
+
+func (provider *chunkRecorder) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	request := strings.Join(symbols, ",")
+
+	provider.mutex.Lock()
+	provider.requests = append(provider.requests, request)
+	provider.inFlight++
+
+	if provider.inFlight > provider.maximum {
+		provider.maximum = provider.inFlight
+	}
+
+	provider.mutex.Unlock()
+
+	if provider.release != nil {
+		<-provider.release
+	}
+
+	provider.mutex.Lock()
+	provider.inFlight--
+	provider.mutex.Unlock()
+
+	if err := provider.failing[request]; err != nil {
+		return nil, err
+	}
+
+	result := &ExchangesResult{Exchanges: make(map[string]Exchange)}
+
+	for _, symbol := range symbols {
+		result.Exchanges[symbol] = Exchange{Symbol: symbol}
+	}
+
+	return result, nil
+}
+
+func TestChunkedProviderSplitsSymbols(t *testing.T) {
+	recorder := &chunkRecorder{}
+	provider := &ChunkedProvider{Provider: recorder, Size: 2}
+
+	result, err := provider.Quotes(context.Background(), []string{"A", "B", "C", "D", "E"})
+
+	if err != nil {
+		t.Fatalf("Chunked request should succeed, but returned %v", err)
+	}
+
+	sort.Strings(recorder.requests)
+
+	if requests := strings.Join(recorder.requests, ";"); requests != "A,B;C,D;E" {
+		t.Fatalf("Symbols should be split in chunks of 2, but requests were %s", requests)
+	}
+
+	if len(result.Exchanges) != 5 {
+		t.Fatalf("Chunks should be merged into one result, but result has %v", result.Exchanges)
+	}
+}
+
+func TestChunkedProviderPassesSmallListsThrough(t *testing.T) {
+	recorder := &chunkRecorder{}
+	provider := &ChunkedProvider{Provider: recorder, Size: 5}
+
+	provider.Quotes(context.Background(), []string{"A", "B"})
+
+	if len(recorder.requests) != 1 || recorder.requests[0] != "A,B" {
+		t.Fatalf("Small list should be requested at once, but requests were %v", recorder.requests)
+	}
+}
+
+func TestChunkedProviderBoundsConcurrentChunks(t *testing.T) {
+	recorder := &chunkRecorder{release: make(chan struct{})}
+	provider := &ChunkedProvider{Provider: recorder, Size: 1, Workers: 2}
+	done := make(chan struct{})
+
+	go func() {
+		provider.Quotes(context.Background(), []string{"A", "B", "C", "D", "E", "F"})
+		close(done)
+	}()
+
+	for i := 0; i < 6; i++ {
+		recorder.release <- struct{}{}
+	}
+
+	<-done
+
+	if recorder.maximum > 2 {
+		t.Fatalf("At most 2 chunks should be in flight, but there were %d", recorder.maximum)
+	}
+}
+
+func TestChunkedProviderKeepsChunkErrors(t *testing.T) {
+	upstreamErr := errors.New("URI too long")
+	recorder := &chunkRecorder{failing: map[string]error{"C,D": upstreamErr}}
+	provider := &ChunkedProvider{Provider: recorder, Size: 2}
+
+	result, err := provider.Quotes(context.Background(), []string{"A", "B", "C", "D"})
+
+	if err != nil {
+		t.Fatalf("Partially failed request should not return error, but returned %v", err)
+	}
+
+	for _, symbol := range []string{"A", "B"} {
+		if _, ok := result.Exchanges[symbol]; !ok {
+			t.Fatalf("Successful chunk should be kept, but %s is missing", symbol)
+		}
+	}
+
+	for _, symbol := range []string{"C", "D"} {
+		if result.Errors[symbol] != upstreamErr {
+			t.Fatalf("Failed chunk symbols should carry chunk error, but %s has %v", symbol, result.Errors[symbol])
+		}
+	}
+}
+
+func TestChunkedProviderFailsWhenEveryChunkFails(t *testing.T) {
+	upstreamErr := errors.New("URI too long")
+	recorder := &chunkRecorder{failing: map[string]error{"A": upstreamErr, "B": upstreamErr}}
+	provider := &ChunkedProvider{Provider: recorder, Size: 1}
+
+	result, err := provider.Quotes(context.Background(), []string{"A", "B"})
+
+	if err != upstreamErr {
+		t.Fatalf("Request should fail when all chunks fail, but returned %v", err)
+	}
+
+	if len(result.Errors) != 2 {
+		t.Fatalf("All symbols should carry chunk error, but errors are %v", result.Errors)
+	}
+}
@@ -0,0 +1,115 @@
+package exchange
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+type Coalescer struct {
+	Provider Provider
+	mutex    sync.Mutex
+	calls    map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done      chan struct{}
+	exchange  Exchange
+	found     bool
+	err       error
+	abandoned bool
+}
+
+func (coalescer *Coalescer) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	result := &ExchangesResult{Exchanges: make(map[string]Exchange)}
+	pending := symbols
+	var err error
+
+	for len(pending) > 0 {
+		calls, leading := coalescer.join(pending)
+
+		if len(leading) > 0 {
+			if leadErr := coalescer.lead(ctx, leading); leadErr != nil {
+				err = leadErr
+			}
+		}
+
+		var retry []string
+
+		for i, symbol := range pending {
+			call := calls[i]
+
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				result.setError(symbol, ctx.Err())
+				continue
+			}
+
+			switch {
+			case call.found:
+				result.Exchanges[symbol] = call.exchange
+			case call.abandoned && ctx.Err() == nil:
+				retry = append(retry, symbol)
+			default:
+				result.setError(symbol, call.err)
+			}
+		}
+
+		pending = retry
+	}
+
+	return result, err
+}
+
+func (coalescer *Coalescer) join(symbols []string) ([]*coalescedCall, []string) {
+	coalescer.mutex.Lock()
+	defer coalescer.mutex.Unlock()
+
+	if coalescer.calls == nil {
+		coalescer.calls = make(map[string]*coalescedCall)
+	}
+
+	calls := make([]*coalescedCall, len(symbols))
+	var leading []string
+
+	for i, symbol := range symbols {
+		key := strings.ToUpper(symbol)
+		call, ok := coalescer.calls[key]
+
+		if !ok {
+			call = &coalescedCall{done: make(chan struct{})}
+			coalescer.calls[key] = call
+			leading = append(leading, symbol)
+		}
+
+		calls[i] = call
+	}
+
+	return calls, leading
+}
+
+func (coalescer *Coalescer) lead(ctx context.Context, symbols []string) error {
+	fetched, err := coalescer.Provider.Quotes(ctx, symbols)
+
+	coalescer.mutex.Lock()
+	defer coalescer.mutex.Unlock()
+
+	for _, symbol := range symbols {
+		key := strings.ToUpper(symbol)
+		call := coalescer.calls[key]
+		delete(coalescer.calls, key)
+
+		if fetched == nil {
+			call.err = err
+		} else if call.exchange, call.found = fetched.Lookup(symbol); !call.found {
+			call.err = fetched.errorFor(symbol, err)
+		}
+
+		call.abandoned = !call.found && ctx.Err() != nil
+
+		close(call.done)
+	}
+
+	return err
+}
@@ -0,0 +1,196 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type gatedProvider struct {
+	mutex    sync.Mutex
+	requests []string
+	started  chan string
+	release  chan struct{}
+	err      error
+}
+
+func newGatedProvider() *gatedProvider {
+	return &gatedProvider{started: make(chan string, 10), release: make(chan struct{})}
+}
+
+func (provider *gatedProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	request := strings.Join(symbols, ",")
+
+	provider.mutex.Lock()
+	provider.requests = append(provider.requests, request)
+	provider.mutex.Unlock()
+
+	provider.started <- request
+
+	select {
+	case <-provider.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if provider.err != nil {
+		return nil, provider.err
+	}
+
+	result := &ExchangesResult{Exchanges: make(map[string]Exchange)}
+
+	for _, symbol := range symbols {
+		result.Exchanges[symbol] = Exchange{Name: symbol + " Inc.", Symbol: symbol}
+	}
+
+	return result, nil
+}
+
+type partialProvider struct {
+	err error
+}
+
+func (provider *partialProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	result := &ExchangesResult{Exchanges: map[string]Exchange{symbols[0]: Exchange{Symbol: symbols[0]}}}
+
+	return result, provider.err
+}
+
+type coalescedResult struct {
+	result *ExchangesResult
+	err    error
+}
+
+func requestAsync(provider Provider, ctx context.Context, symbols ...string) chan coalescedResult {
+	results := make(chan coalescedResult, 1)
+
+	go func() {
+		result, err := provider.Quotes(ctx, symbols)
+		results <- coalescedResult{result, err}
+	}()
+
+	return results
+}
+
+func TestCoalescerSharesInFlightRequests(t *testing.T) {
+	provider := newGatedProvider()
+	coalescer := &Coalescer{Provider: provider}
+
+	first := requestAsync(coalescer, context.Background(), "AAPL")
+	<-provider.started
+	second := requestAsync(coalescer, context.Background(), "aapl", "GOOGL")
+	<-provider.started
+	close(provider.release)
+
+	firstResult, secondResult := <-first, <-second
+
+	if strings.Join(provider.requests, ";") != "AAPL;GOOGL" {
+		t.Fatalf("Coalescer should request each symbol once, but requested %v", provider.requests)
+	}
+
+	if _, ok := firstResult.result.Exchanges["AAPL"]; !ok {
+		t.Fatalf("First request should receive AAPL, but received %v", firstResult.result.Exchanges)
+	}
+
+	for _, symbol := range []string{"aapl", "GOOGL"} {
+		if _, ok := secondResult.result.Exchanges[symbol]; !ok {
+			t.Fatalf("Second request should receive %s, but received %v", symbol, secondResult.result.Exchanges)
+		}
+	}
+}
+
+func TestCoalescerSharesProviderErrors(t *testing.T) {
+	provider := newGatedProvider()
+	provider.err = errors.New("connection refused")
+	coalescer := &Coalescer{Provider: provider}
+
+	first := requestAsync(coalescer, context.Background(), "AAPL")
+	<-provider.started
+	second := requestAsync(coalescer, context.Background(), "AAPL")
+
+	close(provider.release)
+
+	if result := <-first; result.err != provider.err || result.result.Errors["AAPL"] != provider.err {
+		t.Fatalf("Leading request should receive provider error, but received %v", result.result.Errors)
+	}
+
+	if result := <-second; result.result.Errors["AAPL"] != provider.err {
+		t.Fatalf("Waiting request should receive provider error, but received %v", result.result.Errors)
+	}
+}
+
+func TestCoalescerStopsWaitingWhenContextIsDone(t *testing.T) {
+	provider := newGatedProvider()
+	coalescer := &Coalescer{Provider: provider}
+
+	first := requestAsync(coalescer, context.Background(), "AAPL")
+	<-provider.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, _ := coalescer.Quotes(ctx, []string{"AAPL"})
+
+	if result.Errors["AAPL"] != context.Canceled {
+		t.Fatalf("Waiting request should be cancelled with its context, but received %v", result.Errors)
+	}
+
+	close(provider.release)
+
+	if result := <-first; result.err != nil {
+		t.Fatalf("Leading request should not be affected by waiting context, but returned %v", result.err)
+	}
+}
+
+func TestCoalescerReleasesFinishedRequests(t *testing.T) {
+	provider := newGatedProvider()
+	close(provider.release)
+	coalescer := &Coalescer{Provider: provider}
+
+	coalescer.Quotes(context.Background(), []string{"AAPL"})
+	coalescer.Quotes(context.Background(), []string{"AAPL"})
+
+	if len(provider.requests) != 2 {
+		t.Fatalf("Finished requests should not be shared, but provider was called %d times", len(provider.requests))
+	}
+}
+
+func TestCoalescerRetriesWhenLeadingContextIsDone(t *testing.T) {
+	provider := newGatedProvider()
+	coalescer := &Coalescer{Provider: provider}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := requestAsync(coalescer, ctx, "AAPL")
+	<-provider.started
+	second := requestAsync(coalescer, context.Background(), "AAPL")
+
+	cancel()
+	<-first
+	<-provider.started
+	close(provider.release)
+
+	if result := <-second; result.err != nil || result.result.Exchanges["AAPL"].Symbol != "AAPL" {
+		t.Fatalf("Waiting request should fetch AAPL again, but received %v", result.result.Errors)
+	}
+}
+
+func TestCoalescerKeepsProviderErrorForMissingSymbols(t *testing.T) {
+	provider := &partialProvider{err: errors.New("malformed response")}
+	coalescer := &Coalescer{Provider: provider}
+
+	result, err := coalescer.Quotes(context.Background(), []string{"AAPL", "GOOGL"})
+
+	if err != provider.err {
+		t.Fatalf("Coalescer should return provider error, but returned %v", err)
+	}
+
+	if _, ok := result.Exchanges["AAPL"]; !ok {
+		t.Fatalf("Coalescer should keep fetched AAPL, but received %v", result.Exchanges)
+	}
+
+	if result.Errors["GOOGL"] != provider.err {
+		t.Fatalf("Missing GOOGL should report provider error, but reported %v", result.Errors["GOOGL"])
+	}
+}
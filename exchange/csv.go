@@ -0,0 +1,219 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const csvChunkSize = 100
+
+var csvContentTypes = []string{"text/csv", "text/plain", "application/csv", "application/octet-stream"}
+
+var DefaultCSVColumns = []string{
+	"symbol", "name", "last", "open", "prev_close", "change", "pct", "date", "time",
+}
+
+var csvColumnSetters = map[string]func(exchange *Exchange, value string){
+	"symbol":     func(exchange *Exchange, value string) { exchange.Symbol = value },
+	"name":       func(exchange *Exchange, value string) { exchange.Name = value },
+	"last":       func(exchange *Exchange, value string) { exchange.Price = parseDecimal(value) },
+	"open":       func(exchange *Exchange, value string) { exchange.OpenPrice = parseDecimal(value) },
+	"prev_close": func(exchange *Exchange, value string) { exchange.PreviousClose = parseDecimal(value) },
+	"change":     func(exchange *Exchange, value string) { exchange.ChangeInPoints = parseDecimal(value) },
+	"pct":        func(exchange *Exchange, value string) { exchange.PercentChange = parsePercent(value) },
+	"date":       func(exchange *Exchange, value string) { exchange.LastTradeDate = value },
+	"time":       func(exchange *Exchange, value string) { exchange.LastTradeTime = value },
+	"high":       func(exchange *Exchange, value string) { exchange.DayHigh = parseDecimal(value) },
+	"low":        func(exchange *Exchange, value string) { exchange.DayLow = parseDecimal(value) },
+	"year_high":  func(exchange *Exchange, value string) { exchange.YearHigh = parseDecimal(value) },
+	"year_low":   func(exchange *Exchange, value string) { exchange.YearLow = parseDecimal(value) },
+	"volume":     func(exchange *Exchange, value string) { exchange.Volume = parseDecimal(value) },
+	"avg_volume": func(exchange *Exchange, value string) { exchange.AverageVolume = parseDecimal(value) },
+	"market_cap": func(exchange *Exchange, value string) { exchange.MarketCap = parseAbbreviated(value) },
+	"currency":   func(exchange *Exchange, value string) { exchange.Currency = value },
+	"exchange":   func(exchange *Exchange, value string) { exchange.StockExchange = value },
+	"-":          func(exchange *Exchange, value string) {},
+}
+
+type CSVProvider struct {
+	URL, SymbolsParameter string
+	Columns               []string
+	SkipHeader            bool
+	Client                *http.Client
+}
+
+type malformedCSVError struct {
+	err error
+}
+
+func init() {
+	RegisterProvider("csv", newCSVProviderFromEnvironment)
+}
+
+func newCSVProviderFromEnvironment(client *http.Client) (Provider, error) {
+	provider := &CSVProvider{
+		URL:              os.Getenv("CSV_URL"),
+		SymbolsParameter: os.Getenv("CSV_SYMBOLS_PARAMETER"),
+		SkipHeader:       os.Getenv("CSV_SKIP_HEADER") == "true",
+		Client:           client,
+	}
+
+	if provider.URL == "" {
+		return nil, &providerConfigurationError{
+			"Provider 'csv' requires CSV_URL environment variable.",
+		}
+	}
+
+	if columns := os.Getenv("CSV_COLUMNS"); columns != "" {
+		provider.Columns = strings.Split(columns, ",")
+	}
+
+	if err := provider.validateColumns(); err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+func (provider *CSVProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	if err := provider.validateColumns(); err != nil {
+		return nil, err
+	}
+
+	requestURL, err := provider.BuildURL(symbols)
+
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := open(ctx, provider.Client, requestURL, csvContentTypes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer responseBody.Close()
+
+	var raw bytes.Buffer
+	result := &ExchangesResult{}
+	err = provider.parse(result, io.TeeReader(responseBody, &raw))
+	result.rawResult = string(bytes.TrimSpace(raw.Bytes()))
+
+	if err != nil {
+		return result, err
+	}
+
+	result.complete(symbols)
+
+	return result, nil
+}
+
+func (provider *CSVProvider) ChunkSize() int {
+	return csvChunkSize
+}
+
+func (provider *CSVProvider) Decode(raw string) (*ExchangesResult, error) {
+	if err := provider.validateColumns(); err != nil {
+		return nil, err
+	}
+
+	result := &ExchangesResult{rawResult: raw}
+
+	return result, provider.parse(result, strings.NewReader(raw))
+}
+
+func (provider *CSVProvider) BuildURL(symbols []string) (string, error) {
+	requestURL, err := url.Parse(provider.URL)
+
+	if err != nil {
+		return "", err
+	}
+
+	parameter := provider.SymbolsParameter
+
+	if parameter == "" {
+		parameter = "s"
+	}
+
+	query := requestURL.Query()
+	query.Set(parameter, strings.Join(symbols, ","))
+	requestURL.RawQuery = query.Encode()
+
+	return requestURL.String(), nil
+}
+
+func (provider *CSVProvider) parse(result *ExchangesResult, body io.Reader) error {
+	result.Exchanges = make(map[string]Exchange)
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	columns := provider.columns()
+
+	for line := 0; ; line++ {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return &malformedCSVError{err: err}
+		}
+
+		if line == 0 && provider.SkipHeader {
+			continue
+		}
+
+		if len(record) < len(columns) {
+			return &malformedCSVError{
+				err: fmt.Errorf("record on line %d has %d columns, expected %d", line+1, len(record), len(columns)),
+			}
+		}
+
+		var exchange Exchange
+
+		for position, column := range columns {
+			csvColumnSetters[column](&exchange, strings.TrimSpace(record[position]))
+		}
+
+		if exchange.Name == "" {
+			exchange.Name = exchange.Symbol
+		}
+
+		exchange.deriveChanges()
+		exchange.resolveLastTrade(exchange.StockExchange)
+		result.Exchanges[exchange.Symbol] = exchange
+	}
+}
+
+func (provider *CSVProvider) columns() []string {
+	if len(provider.Columns) == 0 {
+		return DefaultCSVColumns
+	}
+
+	return provider.Columns
+}
+
+func (provider *CSVProvider) validateColumns() error {
+	for _, column := range provider.columns() {
+		if _, ok := csvColumnSetters[column]; !ok {
+			return &providerConfigurationError{
+				fmt.Sprintf("CSV column '%s' is not supported.", column),
+			}
+		}
+	}
+
+	return nil
+}
+
+func (err *malformedCSVError) Error() string {
+	return fmt.Sprintf("There was a problem when parsing CSV response: %v", err.err)
+}
@@ -0,0 +1,143 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func csvServer(t *testing.T, body string) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if symbols := r.URL.Query().Get("s"); symbols != "AAPL,GOOGL" {
+					t.Errorf("Request should send symbols %s, but sent %s", "AAPL,GOOGL", symbols)
+				}
+
+				w.Header().Set("Content-Type", "text/csv")
+				fmt.Fprint(w, body)
+			},
+		),
+	)
+}
+
+func TestCSVProviderQuotesWithDefaultColumns(t *testing.T) {
+	body := "\"AAPL\",\"Apple Inc.\",155.39,154.18,153.48,\"+1.91\",\"+1.24%\",\"10/5/2017\",\"4:00pm\"\n" +
+		"\"GOOGL\",\"Alphabet Inc.\",78000.00,76592.115,N/A,\"-0.76\",\"-0.09%\",\"10/5/2017\",\"4:00pm\"\n"
+
+	testServer := csvServer(t, body)
+	defer testServer.Close()
+
+	provider := &CSVProvider{URL: testServer.URL}
+
+	result, err := provider.Quotes(context.Background(), []string{"AAPL", "GOOGL"})
+
+	if err != nil {
+		t.Fatalf("Quotes should return results, but returned error: %v", err)
+	}
+
+	expectedList := map[string]Exchange{
+		"AAPL":  Exchange{Name: "Apple Inc.", Symbol: "AAPL", PercentChange: MustParseDecimal("+1.24"), ChangeInPoints: MustParseDecimal("+1.91"), Price: MustParseDecimal("155.39"), PreviousClose: MustParseDecimal("153.48"), OpenPrice: MustParseDecimal("154.18"), LastTradeDate: "10/5/2017", LastTradeTime: "4:00pm", LastTrade: tradeTimeIn("America/New_York", 2017, time.October, 5, 16, 0)},
+		"GOOGL": Exchange{Name: "Alphabet Inc.", Symbol: "GOOGL", PercentChange: MustParseDecimal("-0.09"), ChangeInPoints: MustParseDecimal("-0.76"), Price: MustParseDecimal("78000.00"), OpenPrice: MustParseDecimal("76592.115"), LastTradeDate: "10/5/2017", LastTradeTime: "4:00pm", LastTrade: tradeTimeIn("America/New_York", 2017, time.October, 5, 16, 0)},
+	}
+
+	for symbol, expected := range expectedList {
+		if actual := result.Exchanges[symbol]; actual != expected {
+			t.Fatalf("Parsed exchange %s should be %v, but is %v", symbol, expected, actual)
+		}
+	}
+
+	if result.rawResult != body[:len(body)-1] {
+		t.Fatalf("Raw result should be %v, but is %v", body, result.rawResult)
+	}
+}
+
+func TestCSVProviderQuotesWithCustomColumns(t *testing.T) {
+	body := "symbol,last,ignored,name\nAAPL,155.39,foo,Apple Inc.\nGOOGL,78000.00,bar,Alphabet Inc.\n"
+
+	testServer := csvServer(t, body)
+	defer testServer.Close()
+
+	provider := &CSVProvider{
+		URL:        testServer.URL,
+		Columns:    []string{"symbol", "last", "-", "name"},
+		SkipHeader: true,
+	}
+
+	result, err := provider.Quotes(context.Background(), []string{"AAPL", "GOOGL"})
+
+	if err != nil {
+		t.Fatalf("Quotes should return results, but returned error: %v", err)
+	}
+
+	expected := Exchange{Name: "Apple Inc.", Symbol: "AAPL", Price: MustParseDecimal("155.39")}
+
+	if actual := result.Exchanges["AAPL"]; actual != expected {
+		t.Fatalf("Parsed exchange should be %v, but is %v", expected, actual)
+	}
+
+	if length := len(result.Exchanges); length != 2 {
+		t.Fatalf("Parsed exchanges should have length of %d, but has %d", 2, length)
+	}
+}
+
+func TestCSVProviderQuotesWithShortRecord(t *testing.T) {
+	testServer := csvServer(t, "AAPL,Apple Inc.,155.39\n")
+	defer testServer.Close()
+
+	provider := &CSVProvider{URL: testServer.URL}
+
+	if _, err := provider.Quotes(context.Background(), []string{"AAPL", "GOOGL"}); err == nil {
+		t.Fatal("Quotes with short CSV record should return an error, but nothing happened.")
+	}
+}
+
+func TestCSVProviderQuotesWithUnknownColumn(t *testing.T) {
+	provider := &CSVProvider{URL: "foo.bar", Columns: []string{"symbol", "foo"}}
+
+	if _, err := provider.Quotes(context.Background(), []string{"AAPL"}); err == nil {
+		t.Fatal("Quotes with unknown CSV column should return an error, but nothing happened.")
+	}
+}
+
+func TestCSVProviderBuildURLKeepsExistingQuery(t *testing.T) {
+	provider := &CSVProvider{URL: "https://example.com/quotes.csv?f=snl1", SymbolsParameter: "symbols"}
+	expected := "https://example.com/quotes.csv?f=snl1&symbols=%5EBVSP%2CGOOGL"
+
+	actual, err := provider.BuildURL([]string{"^BVSP", "GOOGL"})
+
+	if err != nil {
+		t.Fatalf("BuildURL should return URL, but returned error: %v", err)
+	}
+
+	if actual != expected {
+		t.Fatalf("Expected URL to be %s, is %s", expected, actual)
+	}
+}
+
+func TestNewProviderForCSVRequiresURL(t *testing.T) {
+	os.Setenv("CSV_URL", "")
+
+	if _, err := NewProvider("csv", nil); err == nil {
+		t.Fatal("NewProvider should return error when CSV URL is not set, but nothing happened.")
+	}
+
+	os.Setenv("CSV_URL", "https://example.com/quotes.csv")
+	os.Setenv("CSV_COLUMNS", "symbol,name,last")
+	defer os.Setenv("CSV_URL", "")
+	defer os.Setenv("CSV_COLUMNS", "")
+
+	provider, err := NewProvider("csv", nil)
+
+	if err != nil {
+		t.Fatalf("NewProvider should return provider, but returned error: %v", err)
+	}
+
+	if columns := provider.(*CSVProvider).Columns; len(columns) != 3 {
+		t.Fatalf("Provider columns should be taken from environment, but are %v", columns)
+	}
+}
@@ -0,0 +1,257 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxDecimalScale = 18
+	percentScale    = 2
+)
+
+var abbreviationExponents = map[string]int{"K": 3, "M": 6, "B": 9, "T": 12}
+
+type Decimal struct {
+	units int64
+	scale int
+	valid bool
+}
+
+type DecimalError struct {
+	Value string
+}
+
+func NewDecimal(units int64, scale int) Decimal {
+	return Decimal{units: units, scale: scale, valid: true}
+}
+
+func ParseDecimal(value string) (Decimal, error) {
+	text := strings.TrimSpace(value)
+	negative := false
+
+	if text != "" && (text[0] == '+' || text[0] == '-') {
+		negative = text[0] == '-'
+		text = text[1:]
+	}
+
+	integer, fraction := text, ""
+
+	if point := strings.Index(text, "."); point >= 0 {
+		integer, fraction = text[:point], text[point+1:]
+	}
+
+	if integer == "" && fraction == "" || len(fraction) > maxDecimalScale {
+		return Decimal{}, &DecimalError{Value: value}
+	}
+
+	var units int64
+
+	for _, digit := range integer + fraction {
+		if digit < '0' || digit > '9' || units > (math.MaxInt64-int64(digit-'0'))/10 {
+			return Decimal{}, &DecimalError{Value: value}
+		}
+
+		units = units*10 + int64(digit-'0')
+	}
+
+	if negative {
+		units = -units
+	}
+
+	return NewDecimal(units, len(fraction)), nil
+}
+
+func MustParseDecimal(value string) Decimal {
+	decimal, err := ParseDecimal(value)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return decimal
+}
+
+func (decimal Decimal) Valid() bool {
+	return decimal.valid
+}
+
+func (decimal Decimal) Sub(other Decimal) Decimal {
+	if !decimal.valid || !other.valid {
+		return Decimal{}
+	}
+
+	scale := decimal.scale
+
+	if other.scale > scale {
+		scale = other.scale
+	}
+
+	units, ok := decimal.rescale(scale)
+	otherUnits, otherOk := other.rescale(scale)
+
+	if !ok || !otherOk || subtractionOverflows(units, otherUnits) {
+		return Decimal{}
+	}
+
+	return NewDecimal(units-otherUnits, scale)
+}
+
+func (decimal Decimal) PercentOf(base Decimal) Decimal {
+	if !decimal.valid || !base.valid || base.units == 0 {
+		return Decimal{}
+	}
+
+	ratio := new(big.Rat).Quo(decimal.rat(), base.rat())
+	ratio.Mul(ratio, big.NewRat(100, 1))
+
+	return parseDecimal(ratio.FloatString(percentScale))
+}
+
+func (decimal Decimal) String() string {
+	if !decimal.valid {
+		return ""
+	}
+
+	sign, units := "", decimal.units
+
+	if units < 0 {
+		sign, units = "-", -units
+	}
+
+	digits := strconv.FormatInt(units, 10)
+
+	if decimal.scale == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= decimal.scale {
+		digits = strings.Repeat("0", decimal.scale-len(digits)+1) + digits
+	}
+
+	point := len(digits) - decimal.scale
+
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (decimal Decimal) Signed() string {
+	if !decimal.valid || decimal.units < 0 {
+		return decimal.String()
+	}
+
+	return "+" + decimal.String()
+}
+
+func (decimal Decimal) MarshalJSON() ([]byte, error) {
+	if !decimal.valid {
+		return []byte("null"), nil
+	}
+
+	return []byte(decimal.String()), nil
+}
+
+func (decimal *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if bytes.Equal(data, []byte("null")) {
+		*decimal = Decimal{}
+		return nil
+	}
+
+	text := string(data)
+
+	if bytes.HasPrefix(data, []byte("\"")) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseDecimal(text)
+
+	if err != nil {
+		return err
+	}
+
+	*decimal = parsed
+
+	return nil
+}
+
+func (decimal Decimal) rescale(scale int) (int64, bool) {
+	return shiftUnits(decimal.units, scale-decimal.scale)
+}
+
+func (decimal Decimal) rat() *big.Rat {
+	denominator := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimal.scale)), nil)
+
+	return new(big.Rat).SetFrac(big.NewInt(decimal.units), denominator)
+}
+
+func parseDecimal(value string) Decimal {
+	decimal, _ := ParseDecimal(value)
+
+	return decimal
+}
+
+func parseAbbreviated(value string) Decimal {
+	text := strings.TrimSpace(value)
+
+	if text == "" {
+		return Decimal{}
+	}
+
+	exponent, ok := abbreviationExponents[strings.ToUpper(text[len(text)-1:])]
+
+	if !ok {
+		return parseDecimal(text)
+	}
+
+	decimal := parseDecimal(text[:len(text)-1])
+
+	if !decimal.valid {
+		return decimal
+	}
+
+	for ; exponent > 0 && decimal.scale > 0; exponent-- {
+		decimal.scale--
+	}
+
+	units, ok := shiftUnits(decimal.units, exponent)
+
+	if !ok {
+		return Decimal{}
+	}
+
+	decimal.units = units
+
+	return decimal
+}
+
+func shiftUnits(units int64, places int) (int64, bool) {
+	for ; places > 0; places-- {
+		if units > math.MaxInt64/10 || units < math.MinInt64/10 {
+			return 0, false
+		}
+
+		units *= 10
+	}
+
+	return units, true
+}
+
+func subtractionOverflows(units, otherUnits int64) bool {
+	return (otherUnits > 0 && units < math.MinInt64+otherUnits) || (otherUnits < 0 && units > math.MaxInt64+otherUnits)
+}
+
+func parsePercent(value string) Decimal {
+	return parseDecimal(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+}
+
+func (err *DecimalError) Error() string {
+	return fmt.Sprintf("Value '%s' is not a decimal number.", err.Value)
+}
@@ -0,0 +1,148 @@
+package exchange
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	results := []struct {
+		value string
+		exp   Decimal
+		text  string
+		fails bool
+	}{
+		{value: "155.39", exp: NewDecimal(15539, 2), text: "155.39"},
+		{value: "78000.0000", exp: NewDecimal(780000000, 4), text: "78000.0000"},
+		{value: "+1.91", exp: NewDecimal(191, 2), text: "1.91"},
+		{value: "-0.0076", exp: NewDecimal(-76, 4), text: "-0.0076"},
+		{value: " 42 ", exp: NewDecimal(42, 0), text: "42"},
+		{value: ".5", exp: NewDecimal(5, 1), text: "0.5"},
+		{value: "0.10000000000000000001", fails: true},
+		{value: "99999999999999999999", fails: true},
+		{value: "N/A", fails: true},
+		{value: "-", fails: true},
+		{value: "1.2.3", fails: true},
+		{value: "", fails: true},
+	}
+
+	for _, r := range results {
+		actual, err := ParseDecimal(r.value)
+
+		if (err != nil) != r.fails {
+			t.Fatalf("Parsing '%s' should fail: %v, but error was %v", r.value, r.fails, err)
+		}
+
+		if r.fails {
+			if _, ok := err.(*DecimalError); !ok || actual.Valid() {
+				t.Fatalf("Parsing '%s' should return DecimalError and missing value, but returned %v and %v", r.value, err, actual)
+			}
+
+			continue
+		}
+
+		if actual != r.exp || actual.String() != r.text {
+			t.Fatalf("Parsing '%s' should result in %s, but result was %s", r.value, r.text, actual)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	results := []struct {
+		value, base     string
+		difference      string
+		percent, signed string
+	}{
+		{value: "155.39", base: "153.48", difference: "1.91", percent: "1.24", signed: "+155.39"},
+		{value: "78000.0000", base: "80000", difference: "-2000.0000", percent: "-2.50", signed: "+78000.0000"},
+		{value: "-1.5", base: "0", difference: "-1.5", percent: "", signed: "-1.5"},
+	}
+
+	for _, r := range results {
+		value, base := MustParseDecimal(r.value), MustParseDecimal(r.base)
+
+		if difference := value.Sub(base).String(); difference != r.difference {
+			t.Fatalf("%s minus %s should be %s, but is %s", r.value, r.base, r.difference, difference)
+		}
+
+		if percent := value.Sub(base).PercentOf(base).String(); percent != r.percent {
+			t.Fatalf("Change from %s to %s should be %s%%, but is %s%%", r.base, r.value, r.percent, percent)
+		}
+
+		if signed := value.Signed(); signed != r.signed {
+			t.Fatalf("Signed form of %s should be %s, but is %s", r.value, r.signed, signed)
+		}
+	}
+
+	if value := MustParseDecimal("1").Sub(Decimal{}); value.Valid() {
+		t.Fatalf("Subtracting a missing value should be missing, but is %s", value)
+	}
+
+	overflows := [][2]string{
+		{"1.000000000000000001", "100"},
+		{"-9223372036854775807", "2"},
+		{"9223372036854775807", "-1"},
+	}
+
+	for _, operands := range overflows {
+		if value := MustParseDecimal(operands[0]).Sub(MustParseDecimal(operands[1])); value.Valid() {
+			t.Fatalf("%s minus %s overflows and should be missing, but is %s", operands[0], operands[1], value)
+		}
+	}
+}
+
+func TestParseAbbreviated(t *testing.T) {
+	results := []struct {
+		value, exp string
+	}{
+		{"800.52B", "800520000000"},
+		{"1.5T", "1500000000000"},
+		{"12.345K", "12345"},
+		{"12.3456K", "12345.6"},
+		{"42", "42"},
+		{"9999999T", ""},
+		{"N/A", ""},
+		{"", ""},
+	}
+
+	for _, r := range results {
+		if actual := parseAbbreviated(r.value).String(); actual != r.exp {
+			t.Fatalf("Abbreviated value '%s' should be parsed as %s, but was %s", r.value, r.exp, actual)
+		}
+	}
+}
+
+func TestDecimalMarshalJSON(t *testing.T) {
+	body, _ := json.Marshal(struct{ Price, Open Decimal }{Price: MustParseDecimal("78000.0000")})
+
+	if string(body) != "{\"Price\":78000.0000,\"Open\":null}" {
+		t.Fatalf("Decimal should keep its precision and marshal missing values as null, but marshaled %s", body)
+	}
+}
+
+func TestDecimalUnmarshalJSON(t *testing.T) {
+	results := []struct {
+		body  string
+		exp   Decimal
+		fails bool
+	}{
+		{body: "\"155.3900\"", exp: NewDecimal(1553900, 4)},
+		{body: "155.39", exp: NewDecimal(15539, 2)},
+		{body: "null", exp: Decimal{}},
+		{body: "\"N/A\"", fails: true},
+		{body: "true", fails: true},
+	}
+
+	for _, r := range results {
+		var actual Decimal
+		err := json.Unmarshal([]byte(r.body), &actual)
+
+		if (err != nil) != r.fails {
+			t.Fatalf("Unmarshaling %s should fail: %v, but error was %v", r.body, r.fails, err)
+		}
+
+		if !r.fails && actual != r.exp {
+			t.Fatalf("Unmarshaling %s should result in %v, but result was %v", r.body, r.exp, actual)
+		}
+	}
+}
@@ -2,40 +2,99 @@ package exchange
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
+	"net"
 	"net/http"
-	"regexp"
-	"strconv"
 	"strings"
+	"time"
 )
 
-const baseURL = "https://query.yahooapis.com/v1/public/yql?q=select%20*%20from%20yahoo.finance.quotes%20where%20symbol%20in%20(%22INDEXES%22)&format=json&env=store%3A%2F%2Fdatatables.org%2Falltableswithkeys"
+const bodySnippetLength = 256
+
+var ExtendedFields = []string{
+	"DayHigh", "DayLow", "YearHigh", "YearLow", "Volume", "AverageVolume", "MarketCap", "Currency", "StockExchange",
+}
+
+var jsonContentTypes = []string{"application/json", "text/json", "text/javascript"}
 
 type ExchangesResult struct {
 	rawResult string
 	Exchanges map[string]Exchange
+	Errors    map[string]error
+	Resolved  map[string]string
 }
 
 type Exchange struct {
-	Name, Symbol                                                string
-	Price, PreviousClose, OpenPrice                             float64
-	PercentChange, ChangeInPoints, LastTradeDate, LastTradeTime string
+	Name, Symbol                    string
+	Price, PreviousClose, OpenPrice Decimal
+	PercentChange, ChangeInPoints   Decimal
+	LastTradeDate, LastTradeTime    string
+	LastTrade                       TradeTime
+	DayHigh, DayLow                 Decimal
+	YearHigh, YearLow               Decimal
+	Volume, AverageVolume           Decimal
+	MarketCap                       Decimal
+	Currency, StockExchange         string
 }
 
 type malformedJSONError struct {
 	message string
 }
 
-func Fetch(url string) (*ExchangesResult, error) {
-	response, err := http.Get(url)
+type FieldError struct {
+	Symbol, Field string
+	Err           error
+}
+
+type MissingSymbolError struct {
+	Symbol string
+}
+
+type UpstreamStatusError struct {
+	Code        int
+	BodySnippet string
+}
+
+type UnexpectedContentTypeError struct {
+	ContentType string
+	Expected    []string
+}
+
+type providerConfigurationError struct {
+	message string
+}
+
+func NewHTTPClient(connectTimeout, readTimeout time.Duration, policy RetryPolicy) *http.Client {
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+
+	var transport http.RoundTripper = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: readTimeout,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	if policy.MaxAttempts > 1 {
+		transport = &RetryTransport{Base: transport, Policy: policy}
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+func Fetch(ctx context.Context, client *http.Client, url string, contentTypes ...string) (*ExchangesResult, error) {
+	responseBody, err := open(ctx, client, url, contentTypes)
 
 	if err != nil {
 		return nil, err
 	}
 
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+	defer responseBody.Close()
+	body, err := ioutil.ReadAll(responseBody)
 
 	if err != nil {
 		return nil, err
@@ -44,86 +103,179 @@ func Fetch(url string) (*ExchangesResult, error) {
 	return &ExchangesResult{rawResult: string(bytes.TrimSpace(body))}, nil
 }
 
-func BuildURL(indexes []string) string {
-	match := regexp.MustCompile("INDEXES")
-	indexesList := strings.Join(indexes, ",")
+func (exchange Exchange) FormattedChange() string {
+	return exchange.ChangeInPoints.Signed()
+}
 
-	url := match.ReplaceAllString(baseURL, indexesList)
+func (exchange Exchange) FormattedPercentChange() string {
+	if !exchange.PercentChange.Valid() {
+		return ""
+	}
 
-	return url
+	return exchange.PercentChange.Signed() + "%"
 }
 
-func (ex *ExchangesResult) Parse() error {
-	ex.Exchanges = make(map[string]Exchange)
-	exchanges, parsedSuccessfully := ex.parseRawResultToJSON()
+func (exchange *Exchange) deriveChanges() {
+	if !exchange.ChangeInPoints.Valid() {
+		exchange.ChangeInPoints = exchange.Price.Sub(exchange.PreviousClose)
+	}
 
-	if !parsedSuccessfully {
-		return &malformedJSONError{"There was a problem when parsing JSON response."}
+	if !exchange.PercentChange.Valid() {
+		exchange.PercentChange = exchange.ChangeInPoints.PercentOf(exchange.PreviousClose)
 	}
+}
 
-	if exchange, ok := exchanges["quote"].(map[string]interface{}); ok {
-		if result := ex.setExchangeByNameKey(exchange); !result {
-			return &malformedJSONError{"There was a problem when parsing JSON response."}
+func (ex *ExchangesResult) Lookup(symbol string) (Exchange, bool) {
+	_, exchange, ok := ex.lookup(symbol)
+
+	return exchange, ok
+}
+
+func (ex *ExchangesResult) lookup(symbol string) (string, Exchange, bool) {
+	for key, exchange := range ex.Exchanges {
+		if strings.EqualFold(exchange.Symbol, symbol) {
+			return key, exchange, true
 		}
 	}
 
-	if exchanges, ok := exchanges["quote"].([]interface{}); ok {
-		for _, exchange := range exchanges {
-			exchange := exchange.(map[string]interface{})
-			if result := ex.setExchangeByNameKey(exchange); !result {
-				return &malformedJSONError{"There was a problem when parsing JSON response."}
-			}
+	return "", Exchange{}, false
+}
+
+func (ex *ExchangesResult) errorFor(symbol string, fallback error) error {
+	for key, err := range ex.Errors {
+		if strings.EqualFold(key, symbol) {
+			return err
 		}
 	}
 
-	return nil
+	if fallback != nil {
+		return fallback
+	}
+
+	return &MissingSymbolError{Symbol: symbol}
 }
 
-func (ex *ExchangesResult) parseRawResultToJSON() (map[string]interface{}, bool) {
-	var jsonMap map[string]interface{}
-	json.Unmarshal([]byte(ex.rawResult), &jsonMap)
+func (ex *ExchangesResult) setError(symbol string, err error) {
+	if ex.Errors == nil {
+		ex.Errors = make(map[string]error)
+	}
 
-	if exchanges, ok := jsonMap["query"].(map[string]interface{}); ok {
-		if exchanges, ok := exchanges["results"].(map[string]interface{}); ok {
-			return exchanges, true
+	ex.Errors[symbol] = err
+}
+
+func (ex *ExchangesResult) complete(symbols []string) {
+	for _, symbol := range symbols {
+		if key, exchange, ok := ex.lookup(symbol); ok {
+			delete(ex.Exchanges, key)
+			ex.Exchanges[symbol] = exchange
+			continue
 		}
+
+		var err error = &MissingSymbolError{Symbol: symbol}
+
+		for key, symbolErr := range ex.Errors {
+			if strings.EqualFold(key, symbol) {
+				err = symbolErr
+				delete(ex.Errors, key)
+				break
+			}
+		}
+
+		ex.setError(symbol, err)
+	}
+}
+
+func FailedResult(symbols []string, err error) *ExchangesResult {
+	result := &ExchangesResult{Exchanges: make(map[string]Exchange)}
+
+	for _, symbol := range symbols {
+		result.setError(symbol, err)
+	}
+
+	return result
+}
+
+func open(ctx context.Context, client *http.Client, url string, contentTypes []string) (io.ReadCloser, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request.WithContext(ctx))
+
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, false
+	if err := validateResponse(response, contentTypes); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+
+	return response.Body, nil
 }
 
-func (ex *ExchangesResult) setExchangeByNameKey(parsedExchange map[string]interface{}) bool {
-	name, ok := parsedExchange["Name"].(string)
+func validateResponse(response *http.Response, contentTypes []string) error {
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		snippet, _ := ioutil.ReadAll(io.LimitReader(response.Body, bodySnippetLength))
+
+		return &UpstreamStatusError{
+			Code:        response.StatusCode,
+			BodySnippet: string(bytes.TrimSpace(snippet)),
+		}
+	}
 
-	if !ok {
-		return false
+	if len(contentTypes) == 0 {
+		return nil
 	}
 
-	ex.Exchanges[name] = Exchange{
-		Name:           name,
-		Symbol:         parsedExchange["Symbol"].(string),
-		PercentChange:  parsedExchange["PercentChange"].(string),
-		ChangeInPoints: parsedExchange["Change"].(string),
-		Price:          parseAsFloat(parsedExchange["LastTradePriceOnly"]),
-		PreviousClose:  parseAsFloat(parsedExchange["PreviousClose"]),
-		OpenPrice:      parseAsFloat(parsedExchange["Open"]),
-		LastTradeDate:  parsedExchange["LastTradeDate"].(string),
-		LastTradeTime:  parsedExchange["LastTradeTime"].(string),
+	contentType := response.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	for _, expected := range contentTypes {
+		if strings.EqualFold(mediaType, expected) {
+			return nil
+		}
 	}
 
-	return true
+	return &UnexpectedContentTypeError{ContentType: contentType, Expected: contentTypes}
 }
 
 func (err *malformedJSONError) Error() string {
 	return err.message
 }
 
-func parseAsFloat(value interface{}) float64 {
-	strValue := value.(string)
-
-	if floatValue, err := strconv.ParseFloat(strValue, 64); err == nil {
-		return floatValue
+func (err *FieldError) Error() string {
+	if err.Err == nil {
+		return fmt.Sprintf("Quote for '%s' is missing field '%s'.", err.Symbol, err.Field)
 	}
 
-	return 0.0
+	return fmt.Sprintf("Quote for '%s' has invalid field '%s': %v", err.Symbol, err.Field, err.Err)
+}
+
+func (err *MissingSymbolError) Error() string {
+	return fmt.Sprintf("No quote was returned for '%s'.", err.Symbol)
+}
+
+func (err *UpstreamStatusError) Error() string {
+	return fmt.Sprintf(
+		"Quote provider responded with status %d %s: %s",
+		err.Code, http.StatusText(err.Code), err.BodySnippet,
+	)
+}
+
+func (err *UnexpectedContentTypeError) Error() string {
+	return fmt.Sprintf(
+		"Quote provider responded with content-type '%s', expected one of: %s.",
+		err.ContentType, strings.Join(err.Expected, ", "),
+	)
+}
+
+func (err *providerConfigurationError) Error() string {
+	return err.message
 }
@@ -1,10 +1,12 @@
 package exchange
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestFetch(t *testing.T) {
@@ -21,219 +23,213 @@ func TestFetch(t *testing.T) {
 
 	defer testServer.Close()
 
-	result, _ := Fetch(testServer.URL)
+	result, _ := Fetch(context.Background(), nil, testServer.URL)
 
 	if result.rawResult != jsonResult {
 		t.Fatalf("Raw result fetched should be %v, is %v", jsonResult, result.rawResult)
 	}
 }
+
 func TestFetchWithRequestError(t *testing.T) {
 	mockURL := "foo.bar"
 
-	_, err := Fetch(mockURL)
+	_, err := Fetch(context.Background(), nil, mockURL)
 
 	if err == nil {
 		t.Fatal("Fetching invalid URL should return an error, but nothing happened.")
 	}
 }
 
-func TestBuildURLWithOneIndex(t *testing.T) {
-	index := []string{"^BVSP"}
-	expected := "https://query.yahooapis.com/v1/public/yql?q=select%20*%20from%20yahoo.finance.quotes%20where%20symbol%20in%20(%22^BVSP%22)&format=json&env=store%3A%2F%2Fdatatables.org%2Falltableswithkeys"
-
-	actual := BuildURL(index)
-
-	if actual != expected {
-		t.Fatalf("Expected URL to be %s, is %s", expected, actual)
-	}
-}
+func TestFetchWithCancelledContext(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, "{}")
+			},
+		),
+	)
 
-func TestBuildURLWithMoreThanOneIndex(t *testing.T) {
-	indexes := []string{"^BVSP", "GOOGL"}
-	expected := "https://query.yahooapis.com/v1/public/yql?q=select%20*%20from%20yahoo.finance.quotes%20where%20symbol%20in%20(%22^BVSP,GOOGL%22)&format=json&env=store%3A%2F%2Fdatatables.org%2Falltableswithkeys"
+	defer testServer.Close()
 
-	actual := BuildURL(indexes)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	if actual != expected {
-		t.Fatalf("Expected URL to be %s, is %s", expected, actual)
+	if _, err := Fetch(ctx, nil, testServer.URL); err == nil {
+		t.Fatal("Fetching with cancelled context should return an error, but nothing happened.")
 	}
 }
 
-func TestParseForOneIndex(t *testing.T) {
-	jsonResult := "{\"query\":{\"results\":{\"quote\":{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"PercentChange\":\"-0.91%\",\"Change\":\"-172.98\",\"LastTradeDate\":\"4/14/2017\",\"LastTradeTime\":\"3:15pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"70000.0000\",\"LastTradePriceOnly\":\"78000.0000\"}}}}"
-
-	exchangeResult := ExchangesResult{rawResult: jsonResult}
-
-	exchangeResult.Parse()
-
-	for key, exchange := range exchangeResult.Exchanges {
-		if key != "Nikkei 225" {
-			t.Fatalf("Exchanges list should have key %s, but it is %s", "Nikkei 225", key)
-		}
-
-		if exchange.Name != "Nikkei 225" {
-			t.Fatalf("Exchange name should be %s, is %s", "Nikkei 225", exchange.Name)
-		}
-
-		if exchange.Symbol != "^n225" {
-			t.Fatalf("Exchange symbol should be %s, is %s", "^n225", exchange.Symbol)
-		}
-
-		if exchange.PercentChange != "-0.91%" {
-			t.Fatalf("Parsed percent change should be %s, is %s", "-0.91%", exchange.PercentChange)
-		}
-
-		if exchange.ChangeInPoints != "-172.98" {
-			t.Fatalf("Parsed change in points should be %s, is %s", "-172.98", exchange.ChangeInPoints)
-		}
-
-		if exchange.Price != 78000.0000 {
-			t.Fatalf("Parsed price should be %f, is %f", 78000.0000, exchange.Price)
-		}
+func TestFetchWithClientReadTimeout(t *testing.T) {
+	release := make(chan bool)
 
-		if exchange.LastTradeDate != "4/14/2017" {
-			t.Fatalf("Parsed 'last trade date' should be %s, is %s", "4/14/2017", exchange.LastTradeDate)
-		}
+	testServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				<-release
+				fmt.Fprintln(w, "{}")
+			},
+		),
+	)
 
-		if exchange.LastTradeTime != "3:15pm" {
-			t.Fatalf("Parsed 'last trade time' should be %s, is %s", "3:15pm", exchange.LastTradeTime)
-		}
+	defer testServer.Close()
+	defer close(release)
 
-		if exchange.PreviousClose != 70000.0000 {
-			t.Fatalf("Parsed 'previous close' should be %f, is %f", 70000.0000, exchange.PreviousClose)
-		}
+	client := NewHTTPClient(time.Second, 50*time.Millisecond, RetryPolicy{})
 
-		if exchange.OpenPrice != 76592.1150 {
-			t.Fatalf("Parsed 'open price' should be %f, is %f", 76592.1150, exchange.OpenPrice)
-		}
+	if _, err := Fetch(context.Background(), client, testServer.URL); err == nil {
+		t.Fatal("Fetching from hung server should return an error after read timeout, but nothing happened.")
 	}
 }
 
-func TestParseWithZeroValueWhenAnyFloatIsNotParseable(t *testing.T) {
-	jsonResult := "{\"query\":{\"results\":{\"quote\":{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"PercentChange\":\"-0.91%\",\"Change\":\"-172.98\",\"LastTradeDate\":\"4/14/2017\",\"LastTradeTime\":\"3:15pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"-\",\"LastTradePriceOnly\":\"78000.0000\"}}}}"
+func TestFetchReturnsUpstreamStatusError(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprintln(w, "<html><body>Service Unavailable</body></html>")
+			},
+		),
+	)
 
-	exchangeResult := ExchangesResult{rawResult: jsonResult}
+	defer testServer.Close()
 
-	exchangeResult.Parse()
+	_, err := Fetch(context.Background(), nil, testServer.URL, jsonContentTypes...)
 
-	for key, exchange := range exchangeResult.Exchanges {
-		if key != "Nikkei 225" {
-			t.Fatalf("Exchanges list should have key %s, but it is %s", "Nikkei 225", key)
-		}
+	statusErr, ok := err.(*UpstreamStatusError)
 
-		if exchange.Name != "Nikkei 225" {
-			t.Fatalf("Exchange name should be %s, is %s", "Nikkei 225", exchange.Name)
-		}
+	if !ok {
+		t.Fatalf("Fetch should return UpstreamStatusError, but returned %v", err)
+	}
 
-		if exchange.Symbol != "^n225" {
-			t.Fatalf("Exchange symbol should be %s, is %s", "^n225", exchange.Symbol)
-		}
+	if statusErr.Code != http.StatusServiceUnavailable || statusErr.BodySnippet != "<html><body>Service Unavailable</body></html>" {
+		t.Fatalf("UpstreamStatusError should have status code and body snippet, but is %v", statusErr)
+	}
+}
 
-		if exchange.PercentChange != "-0.91%" {
-			t.Fatalf("Parsed percent change should be %s, is %s", "-0.91%", exchange.PercentChange)
-		}
+func TestFetchReturnsUnexpectedContentTypeError(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				fmt.Fprintln(w, "<html></html>")
+			},
+		),
+	)
 
-		if exchange.ChangeInPoints != "-172.98" {
-			t.Fatalf("Parsed change in points should be %s, is %s", "-172.98", exchange.ChangeInPoints)
-		}
+	defer testServer.Close()
 
-		if exchange.Price != 78000.0000 {
-			t.Fatalf("Parsed price should be %f, is %f", 78000.0000, exchange.Price)
-		}
+	_, err := Fetch(context.Background(), nil, testServer.URL, jsonContentTypes...)
 
-		if exchange.LastTradeDate != "4/14/2017" {
-			t.Fatalf("Parsed 'last trade date' should be %s, is %s", "4/14/2017", exchange.LastTradeDate)
-		}
+	if contentTypeErr, ok := err.(*UnexpectedContentTypeError); !ok || contentTypeErr.ContentType != "text/html; charset=utf-8" {
+		t.Fatalf("Fetch should return UnexpectedContentTypeError, but returned %v", err)
+	}
+}
 
-		if exchange.LastTradeTime != "3:15pm" {
-			t.Fatalf("Parsed 'last trade time' should be %s, is %s", "3:15pm", exchange.LastTradeTime)
-		}
+func TestFetchAcceptsContentTypeWithParameters(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json;charset=utf-8")
+				fmt.Fprintln(w, "{}")
+			},
+		),
+	)
 
-		if exchange.PreviousClose != 0.0 {
-			t.Fatalf("Parsed 'previous close' should be %f, is %f", 0.0, exchange.PreviousClose)
-		}
+	defer testServer.Close()
 
-		if exchange.OpenPrice != 76592.1150 {
-			t.Fatalf("Parsed 'open price' should be %f, is %f", 76592.1150, exchange.OpenPrice)
-		}
+	if _, err := Fetch(context.Background(), nil, testServer.URL, jsonContentTypes...); err != nil {
+		t.Fatalf("Fetch should accept JSON content-type with parameters, but returned %v", err)
 	}
 }
 
-func TestParseForMoreThanOneIndex(t *testing.T) {
-	exchangeResult := ExchangesResult{
-		rawResult: "{\"query\":{\"results\":{\"quote\":[{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"PercentChange\":\"-0.91%\",\"Change\":\"-172.98\",\"LastTradeDate\":\"4/14/2017\",\"LastTradeTime\":\"3:15pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"70000.0000\",\"LastTradePriceOnly\":\"78000.0000\"},{\"Name\":\"Alphabet Inc.\",\"Symbol\":\"GOOGL\",\"PercentChange\":\"-0.09%\",\"Change\":\"-0.76\",\"LastTradeDate\":\"4/13/2017\",\"LastTradeTime\":\"4:00pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"70000.0000\",\"LastTradePriceOnly\":\"78000.0000\"}]}}}",
-	}
+func TestFailedResultSetsErrorForEverySymbol(t *testing.T) {
+	err := &UpstreamStatusError{Code: http.StatusBadGateway}
 
-	exchangeResult.Parse()
+	result := FailedResult([]string{"AAPL", "GOOGL"}, err)
 
-	expectedList := map[string]Exchange{
-		"Nikkei 225":    Exchange{Name: "Nikkei 225", Symbol: "^n225", PercentChange: "-0.91%", ChangeInPoints: "-172.98", Price: 78000.0000, PreviousClose: 70000.0000, OpenPrice: 76592.1150, LastTradeDate: "4/14/2017", LastTradeTime: "3:15pm"},
-		"Alphabet Inc.": Exchange{Name: "Alphabet Inc.", Symbol: "GOOGL", PercentChange: "-0.09%", ChangeInPoints: "-0.76", Price: 78000.0000, PreviousClose: 70000.0000, OpenPrice: 76592.1150, LastTradeDate: "4/13/2017", LastTradeTime: "4:00pm"},
+	if len(result.Exchanges) != 0 || result.Errors["AAPL"] != err || result.Errors["GOOGL"] != err {
+		t.Fatalf("FailedResult should set error for every symbol, but is %v", result)
 	}
+}
 
-	nikkei := exchangeResult.Exchanges["Nikkei 225"]
-
-	if nikkei != expectedList["Nikkei 225"] {
-		t.Fatalf("Parsed exchanges list should have a %s exchange and it should be equal to %v, but it is %v", "Nikkei 225", expectedList["Nikkei 225"], nikkei)
+func TestUpstreamErrorsMessages(t *testing.T) {
+	results := []struct {
+		err error
+		exp string
+	}{
+		{err: &UpstreamStatusError{Code: 503, BodySnippet: "down"}, exp: "Quote provider responded with status 503 Service Unavailable: down"},
+		{err: &UnexpectedContentTypeError{ContentType: "text/html", Expected: []string{"application/json"}}, exp: "Quote provider responded with content-type 'text/html', expected one of: application/json."},
 	}
 
-	google := exchangeResult.Exchanges["Alphabet Inc."]
-
-	if google != expectedList["Alphabet Inc."] {
-		t.Fatalf("Parsed exchanges list should have a %s exchange and it should be equal to %v, but it is %v", "Alphabet Inc.", expectedList["Alphabet Inc."], google)
+	for _, r := range results {
+		if msg := r.err.Error(); msg != r.exp {
+			t.Fatalf("Error message should be %s, but is %s", r.exp, msg)
+		}
 	}
 }
 
-func TestParseForMalformedJSONQuery(t *testing.T) {
-	exchangeResult := ExchangesResult{rawResult: "{\"foo\":{}}"}
+func TestError(t *testing.T) {
+	err := malformedJSONError{"Message"}
 
-	err := exchangeResult.Parse()
+	msg := err.Error()
 
-	if err == nil {
-		t.Fatal("Parse() with malformed JSON response should return error, but returned nothing.")
+	if msg != "Message" {
+		t.Fatalf("Error message should be %s, but is %s", "Message", msg)
 	}
 }
 
-func TestParseForMalformedJSONResults(t *testing.T) {
-	exchangeResult := ExchangesResult{rawResult: "{\"query\":{\"results\":null}}"}
-
-	err := exchangeResult.Parse()
+func TestFieldError(t *testing.T) {
+	results := []struct {
+		err *FieldError
+		exp string
+	}{
+		{err: &FieldError{Symbol: "AAPL", Field: "Open"}, exp: "Quote for 'AAPL' is missing field 'Open'."},
+		{err: &FieldError{Symbol: "AAPL", Field: "Open", Err: fmt.Errorf("foo")}, exp: "Quote for 'AAPL' has invalid field 'Open': foo"},
+	}
 
-	if err == nil {
-		t.Fatal("Parse() with malformed JSON response should return error, but returned nothing.")
+	for _, r := range results {
+		if msg := r.err.Error(); msg != r.exp {
+			t.Fatalf("Error message should be %s, but is %s", r.exp, msg)
+		}
 	}
 }
 
-func TestParseForMalformedJSONQuoteKeyWithOneIndex(t *testing.T) {
-	exchangeResult := ExchangesResult{
-		rawResult: "{\"query\":{\"results\":{\"quote\":{\"Name\":null,\"Symbol\":\"foo\",\"PercentChange\":null,\"Change\":null,\"LastTradeDate\":null,\"LastTradeTime\":null}}}}",
+func TestLookupFindsExchangeBySymbolIgnoringCase(t *testing.T) {
+	result := &ExchangesResult{
+		Exchanges: map[string]Exchange{"^n225": Exchange{Name: "Nikkei 225", Symbol: "^n225"}},
 	}
 
-	err := exchangeResult.Parse()
+	if exchange, ok := result.Lookup("^N225"); !ok || exchange.Name != "Nikkei 225" {
+		t.Fatalf("Lookup should find exchange by symbol, but found %v", exchange)
+	}
 
-	if err == nil {
-		t.Fatal("Parse() with malformed JSON response should return error, but returned nothing.")
+	if _, ok := result.Lookup("FOO"); ok {
+		t.Fatal("Lookup should not find exchange for unknown symbol")
 	}
 }
 
-func TestParseForMalformedJSONQuoteKeyWithMoreThanOneIndex(t *testing.T) {
-	exchangeResult := ExchangesResult{
-		rawResult: "{\"query\":{\"results\":{\"quote\":[{\"Name\":null,\"Symbol\":\"foo\",\"PercentChange\":null,\"Change\":null,\"LastTradeDate\":null,\"LastTradeTime\":null},{\"Name\":null,\"Symbol\":\"boo\",\"PercentChange\":null,\"Change\":null,\"LastTradeDate\":null,\"LastTradeTime\":null}]}}}",
+func TestCompleteSetsErrorsForRequestedSymbols(t *testing.T) {
+	result := &ExchangesResult{
+		Exchanges: map[string]Exchange{"^n225": Exchange{Name: "Nikkei 225", Symbol: "^n225"}},
+		Errors:    map[string]error{"foo": &FieldError{Symbol: "foo", Field: "Name"}},
 	}
 
-	err := exchangeResult.Parse()
+	result.complete([]string{"^N225", "FOO", "BAR"})
 
-	if err == nil {
-		t.Fatal("Parse() with malformed JSON response should return error, but returned nothing.")
+	if _, ok := result.Exchanges["^N225"]; !ok || len(result.Exchanges) != 1 {
+		t.Fatalf("Exchanges should be keyed by requested symbol, but are %v", result.Exchanges)
 	}
-}
 
-func TestError(t *testing.T) {
-	err := malformedJSONError{"Message"}
+	if _, ok := result.Errors["FOO"].(*FieldError); !ok {
+		t.Fatalf("Errors should be keyed by requested symbol, but are %v", result.Errors)
+	}
 
-	msg := err.Error()
+	if _, ok := result.Errors["BAR"].(*MissingSymbolError); !ok {
+		t.Fatalf("Symbol without quote should have MissingSymbolError, but errors are %v", result.Errors)
+	}
 
-	if msg != "Message" {
-		t.Fatalf("Error message should be %s, but is %s", "Message", msg)
+	if length := len(result.Errors); length != 2 {
+		t.Fatalf("Errors should have length of %d, but has %d: %v", 2, length, result.Errors)
 	}
 }
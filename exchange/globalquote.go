@@ -0,0 +1,230 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+const (
+	globalQuoteURL       = "https://www.alphavantage.co/query"
+	globalQuoteChunkSize = 1
+)
+
+type GlobalQuoteProvider struct {
+	URL, APIKey string
+	Client      *http.Client
+}
+
+type RateLimitError struct {
+	Message string
+}
+
+type ProviderMessageError struct {
+	Message string
+}
+
+type globalQuoteResponse struct {
+	Quote        *globalQuote `json:"Global Quote"`
+	Note         string       `json:"Note"`
+	Information  string       `json:"Information"`
+	ErrorMessage string       `json:"Error Message"`
+}
+
+type globalQuote struct {
+	Symbol           string `json:"01. symbol"`
+	Open             string `json:"02. open"`
+	High             string `json:"03. high"`
+	Low              string `json:"04. low"`
+	Price            string `json:"05. price"`
+	Volume           string `json:"06. volume"`
+	LatestTradingDay string `json:"07. latest trading day"`
+	PreviousClose    string `json:"08. previous close"`
+	Change           string `json:"09. change"`
+	ChangePercent    string `json:"10. change percent"`
+}
+
+func init() {
+	RegisterProvider("globalquote", newGlobalQuoteProviderFromEnvironment)
+}
+
+func newGlobalQuoteProviderFromEnvironment(client *http.Client) (Provider, error) {
+	apiKey := os.Getenv("GLOBAL_QUOTE_API_KEY")
+
+	if apiKey == "" {
+		return nil, &providerConfigurationError{
+			"Provider 'globalquote' requires GLOBAL_QUOTE_API_KEY environment variable.",
+		}
+	}
+
+	return &GlobalQuoteProvider{
+		URL:    os.Getenv("GLOBAL_QUOTE_URL"),
+		APIKey: apiKey,
+		Client: client,
+	}, nil
+}
+
+func (provider *GlobalQuoteProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	bodies := make(map[string]json.RawMessage, len(symbols))
+	fetchErrs := make(map[string]error)
+	var rateLimitErr, fetchErr, messageErr error
+
+	for _, symbol := range symbols {
+		if rateLimitErr != nil {
+			break
+		}
+
+		response, err := Fetch(ctx, provider.Client, provider.buildURL(symbol), jsonContentTypes...)
+
+		if err != nil {
+			if fetchErr == nil {
+				fetchErr = err
+			}
+
+			fetchErrs[symbol] = err
+			continue
+		}
+
+		bodies[symbol] = json.RawMessage(response.rawResult)
+
+		if _, err := decodeGlobalQuote(symbol, bodies[symbol]); err != nil {
+			switch err.(type) {
+			case *RateLimitError:
+				rateLimitErr = err
+			case *ProviderMessageError:
+				messageErr = err
+			}
+		}
+	}
+
+	raw, err := json.Marshal(bodies)
+
+	if err != nil {
+		return nil, &malformedJSONError{"There was a problem when parsing JSON response."}
+	}
+
+	result, err := provider.Decode(string(raw))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, symbol := range symbols {
+		if err, ok := fetchErrs[symbol]; ok {
+			result.setError(symbol, err)
+		} else if _, ok := bodies[symbol]; !ok {
+			result.setError(symbol, rateLimitErr)
+		}
+	}
+
+	result.complete(symbols)
+
+	if len(fetchErrs) == len(symbols) {
+		return result, fetchErr
+	}
+
+	if rateLimitErr == nil && messageErr != nil && len(result.Exchanges) == 0 {
+		return result, messageErr
+	}
+
+	return result, rateLimitErr
+}
+
+func (provider *GlobalQuoteProvider) ChunkSize() int {
+	return globalQuoteChunkSize
+}
+
+func (provider *GlobalQuoteProvider) Decode(raw string) (*ExchangesResult, error) {
+	result := &ExchangesResult{rawResult: raw}
+
+	return result, provider.parse(result)
+}
+
+func (provider *GlobalQuoteProvider) buildURL(symbol string) string {
+	endpoint := provider.URL
+
+	if endpoint == "" {
+		endpoint = globalQuoteURL
+	}
+
+	query := url.Values{}
+	query.Set("function", "GLOBAL_QUOTE")
+	query.Set("symbol", symbol)
+	query.Set("apikey", provider.APIKey)
+
+	return endpoint + "?" + query.Encode()
+}
+
+func (provider *GlobalQuoteProvider) parse(result *ExchangesResult) error {
+	result.Exchanges = make(map[string]Exchange)
+
+	var responses map[string]json.RawMessage
+
+	if err := json.Unmarshal([]byte(result.rawResult), &responses); err != nil {
+		return &malformedJSONError{"There was a problem when parsing JSON response."}
+	}
+
+	for symbol, body := range responses {
+		exchange, err := decodeGlobalQuote(symbol, body)
+
+		if err != nil {
+			result.setError(symbol, err)
+			continue
+		}
+
+		result.Exchanges[exchange.Symbol] = exchange
+	}
+
+	return nil
+}
+
+func decodeGlobalQuote(symbol string, body json.RawMessage) (Exchange, error) {
+	var response globalQuoteResponse
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return Exchange{}, &FieldError{Symbol: symbol, Field: "Global Quote", Err: err}
+	}
+
+	if response.Note != "" || response.Information != "" {
+		return Exchange{}, &RateLimitError{Message: response.Note + response.Information}
+	}
+
+	if response.ErrorMessage != "" {
+		return Exchange{}, &ProviderMessageError{Message: response.ErrorMessage}
+	}
+
+	if response.Quote == nil || response.Quote.Symbol == "" {
+		return Exchange{}, &MissingSymbolError{Symbol: symbol}
+	}
+
+	quote := response.Quote
+	exchange := Exchange{
+		Name:           quote.Symbol,
+		Symbol:         quote.Symbol,
+		PercentChange:  parsePercent(quote.ChangePercent),
+		ChangeInPoints: parseDecimal(quote.Change),
+		Price:          parseDecimal(quote.Price),
+		PreviousClose:  parseDecimal(quote.PreviousClose),
+		OpenPrice:      parseDecimal(quote.Open),
+		LastTradeDate:  quote.LatestTradingDay,
+		DayHigh:        parseDecimal(quote.High),
+		DayLow:         parseDecimal(quote.Low),
+		Volume:         parseDecimal(quote.Volume),
+	}
+
+	exchange.deriveChanges()
+	exchange.resolveLastTrade("")
+
+	return exchange, nil
+}
+
+func (err *RateLimitError) Error() string {
+	return fmt.Sprintf("Provider rate limit was reached: %s", err.Message)
+}
+
+func (err *ProviderMessageError) Error() string {
+	return fmt.Sprintf("Provider rejected the request: %s", err.Message)
+}
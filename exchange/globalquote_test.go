@@ -0,0 +1,214 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func globalQuoteServer(t *testing.T, bodies map[string]string) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("function") != "GLOBAL_QUOTE" {
+					t.Errorf("Request should ask for GLOBAL_QUOTE function, but asked for %s", r.URL.Query().Get("function"))
+				}
+
+				if r.URL.Query().Get("apikey") != "secret" {
+					t.Errorf("Request should send API key %s, but sent %s", "secret", r.URL.Query().Get("apikey"))
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintln(w, bodies[r.URL.Query().Get("symbol")])
+			},
+		),
+	)
+}
+
+func TestGlobalQuoteProviderQuotes(t *testing.T) {
+	testServer := globalQuoteServer(t, map[string]string{
+		"IBM":  "{\"Global Quote\":{\"01. symbol\":\"IBM\",\"02. open\":\"160.0000\",\"03. high\":\"162.5000\",\"04. low\":\"159.1000\",\"05. price\":\"161.2000\",\"06. volume\":\"3102400\",\"07. latest trading day\":\"2017-10-05\",\"08. previous close\":\"159.8000\",\"09. change\":\"1.4000\",\"10. change percent\":\"0.8761%\"}}",
+		"AAPL": "{\"Global Quote\":{\"01. symbol\":\"AAPL\",\"02. open\":\"154.1800\",\"03. high\":\"155.4400\",\"04. low\":\"154.0500\",\"05. price\":\"155.3900\",\"06. volume\":\"21283769\",\"07. latest trading day\":\"2017-10-05\",\"08. previous close\":\"153.4800\",\"09. change\":\"1.9100\",\"10. change percent\":\"1.2445%\"}}",
+	})
+
+	defer testServer.Close()
+
+	provider := &GlobalQuoteProvider{URL: testServer.URL, APIKey: "secret"}
+
+	result, err := provider.Quotes(context.Background(), []string{"IBM", "AAPL"})
+
+	if err != nil {
+		t.Fatalf("Quotes should return results, but returned error: %v", err)
+	}
+
+	expected := Exchange{Name: "AAPL", Symbol: "AAPL", PercentChange: MustParseDecimal("1.2445"), ChangeInPoints: MustParseDecimal("1.9100"), Price: MustParseDecimal("155.3900"), PreviousClose: MustParseDecimal("153.4800"), OpenPrice: MustParseDecimal("154.1800"), LastTradeDate: "2017-10-05", DayHigh: MustParseDecimal("155.4400"), DayLow: MustParseDecimal("154.0500"), Volume: MustParseDecimal("21283769")}
+
+	if actual := result.Exchanges["AAPL"]; actual != expected {
+		t.Fatalf("Parsed exchange should be %v, but is %v", expected, actual)
+	}
+
+	if length := len(result.Exchanges); length != 2 {
+		t.Fatalf("Parsed exchanges should have length of %d, but has %d", 2, length)
+	}
+}
+
+func TestGlobalQuoteProviderIsChunkedBySymbol(t *testing.T) {
+	var provider Provider = &GlobalQuoteProvider{}
+
+	sizer, ok := provider.(ChunkSizer)
+
+	if !ok || sizer.ChunkSize() != 1 {
+		t.Fatalf("GlobalQuoteProvider should be chunked by %d symbol, but is %v", 1, provider)
+	}
+}
+
+func TestGlobalQuoteProviderQuotesSetsErrorsForUnknownSymbols(t *testing.T) {
+	testServer := globalQuoteServer(t, map[string]string{
+		"FOO": "{\"Global Quote\":{}}",
+		"BAR": "{}",
+	})
+
+	defer testServer.Close()
+
+	provider := &GlobalQuoteProvider{URL: testServer.URL, APIKey: "secret"}
+
+	result, err := provider.Quotes(context.Background(), []string{"FOO", "BAR"})
+
+	if err != nil {
+		t.Fatalf("Quotes should not return error for unknown symbols, but returned: %v", err)
+	}
+
+	if length := len(result.Exchanges); length != 0 {
+		t.Fatalf("Parsed exchanges should be empty, but has length of %d", length)
+	}
+
+	for _, symbol := range []string{"FOO", "BAR"} {
+		if _, ok := result.Errors[symbol].(*MissingSymbolError); !ok {
+			t.Fatalf("Unknown symbol %s should have MissingSymbolError, but errors are %v", symbol, result.Errors)
+		}
+	}
+}
+
+func TestGlobalQuoteProviderQuotesReturnsProviderMessageError(t *testing.T) {
+	testServer := globalQuoteServer(t, map[string]string{
+		"IBM":  "{\"Error Message\":\"the parameter apikey is invalid or missing.\"}",
+		"AAPL": "{\"Error Message\":\"the parameter apikey is invalid or missing.\"}",
+	})
+
+	defer testServer.Close()
+
+	provider := &GlobalQuoteProvider{URL: testServer.URL, APIKey: "secret"}
+
+	result, err := provider.Quotes(context.Background(), []string{"IBM", "AAPL"})
+
+	messageErr, ok := err.(*ProviderMessageError)
+
+	if !ok {
+		t.Fatalf("Quotes should return ProviderMessageError, but returned %v", err)
+	}
+
+	if messageErr.Message != "the parameter apikey is invalid or missing." {
+		t.Fatalf("ProviderMessageError should carry provider message, but carries %s", messageErr.Message)
+	}
+
+	for _, symbol := range []string{"IBM", "AAPL"} {
+		if _, ok := result.Errors[symbol].(*ProviderMessageError); !ok {
+			t.Fatalf("Rejected symbol %s should have ProviderMessageError, but errors are %v", symbol, result.Errors)
+		}
+	}
+}
+
+func TestGlobalQuoteProviderQuotesReturnsRateLimitError(t *testing.T) {
+	testServer := globalQuoteServer(t, map[string]string{
+		"IBM": "{\"Note\":\"Thank you for using our API! Our standard API call frequency is 5 calls per minute.\"}",
+	})
+
+	defer testServer.Close()
+
+	provider := &GlobalQuoteProvider{URL: testServer.URL, APIKey: "secret"}
+
+	result, err := provider.Quotes(context.Background(), []string{"IBM", "AAPL"})
+
+	rateLimitErr, ok := err.(*RateLimitError)
+
+	if !ok {
+		t.Fatalf("Quotes should return RateLimitError, but returned %v", err)
+	}
+
+	if rateLimitErr.Message != "Thank you for using our API! Our standard API call frequency is 5 calls per minute." {
+		t.Fatalf("RateLimitError should carry provider note, but carries %s", rateLimitErr.Message)
+	}
+
+	for _, symbol := range []string{"IBM", "AAPL"} {
+		if _, ok := result.Errors[symbol].(*RateLimitError); !ok {
+			t.Fatalf("Rate limited symbol %s should have RateLimitError, but errors are %v", symbol, result.Errors)
+		}
+	}
+}
+
+func TestGlobalQuoteProviderQuotesKeepsFetchedSymbolsOnRequestError(t *testing.T) {
+	body := "{\"Global Quote\":{\"01. symbol\":\"IBM\",\"05. price\":\"161.2000\",\"07. latest trading day\":\"2017-10-05\",\"08. previous close\":\"159.8000\"}}"
+	testServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("symbol") != "IBM" {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintln(w, body)
+			},
+		),
+	)
+
+	defer testServer.Close()
+
+	provider := &GlobalQuoteProvider{URL: testServer.URL, APIKey: "secret"}
+
+	result, err := provider.Quotes(context.Background(), []string{"IBM", "AAPL"})
+
+	if err != nil {
+		t.Fatalf("Quotes should not fail when some symbols were fetched, but returned %v", err)
+	}
+
+	if _, ok := result.Exchanges["IBM"]; !ok {
+		t.Fatalf("Quotes should keep fetched IBM, but received %v", result.Exchanges)
+	}
+
+	if _, ok := result.Errors["AAPL"].(*UpstreamStatusError); !ok {
+		t.Fatalf("AAPL should report the response error, but errors are %v", result.Errors)
+	}
+}
+
+func TestGlobalQuoteProviderQuotesWithRequestError(t *testing.T) {
+	provider := &GlobalQuoteProvider{URL: "foo.bar", APIKey: "secret"}
+
+	if _, err := provider.Quotes(context.Background(), []string{"IBM"}); err == nil {
+		t.Fatal("Quotes with invalid URL should return an error, but nothing happened.")
+	}
+}
+
+func TestNewProviderForGlobalQuoteRequiresAPIKey(t *testing.T) {
+	os.Setenv("GLOBAL_QUOTE_API_KEY", "")
+
+	if _, err := NewProvider("globalquote", nil); err == nil {
+		t.Fatal("NewProvider should return error when API key is not set, but nothing happened.")
+	}
+
+	os.Setenv("GLOBAL_QUOTE_API_KEY", "secret")
+	defer os.Setenv("GLOBAL_QUOTE_API_KEY", "")
+
+	provider, err := NewProvider("globalquote", nil)
+
+	if err != nil {
+		t.Fatalf("NewProvider should return provider, but returned error: %v", err)
+	}
+
+	if apiKey := provider.(*GlobalQuoteProvider).APIKey; apiKey != "secret" {
+		t.Fatalf("Provider API key should be %s, but is %s", "secret", apiKey)
+	}
+}
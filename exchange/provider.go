@@ -0,0 +1,60 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const DefaultProvider = "yql"
+
+type Provider interface {
+	Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error)
+}
+
+type ProviderFactory func(client *http.Client) (Provider, error)
+
+type UnknownProviderError struct {
+	Name string
+}
+
+var providers = map[string]ProviderFactory{
+	"yql": func(client *http.Client) (Provider, error) {
+		return &YQLProvider{Client: client}, nil
+	},
+}
+
+func RegisterProvider(name string, factory ProviderFactory) {
+	providers[name] = factory
+}
+
+func NewProvider(name string, client *http.Client) (Provider, error) {
+	factory, ok := providers[name]
+
+	if !ok {
+		return nil, &UnknownProviderError{Name: name}
+	}
+
+	return factory(client)
+}
+
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+
+	for name := range providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (err *UnknownProviderError) Error() string {
+	return fmt.Sprintf(
+		"Provider '%s' is not available. Available providers are: %s.",
+		err.Name, strings.Join(ProviderNames(), ", "),
+	)
+}
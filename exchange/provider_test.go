@@ -0,0 +1,57 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+type fakeProvider struct{}
+
+func (provider *fakeProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	return &ExchangesResult{}, nil
+}
+
+func TestNewProviderReturnsYQLProviderByDefault(t *testing.T) {
+	provider, err := NewProvider(DefaultProvider, nil)
+
+	if err != nil {
+		t.Fatalf("NewProvider should return default provider, but returned error: %v", err)
+	}
+
+	if _, ok := provider.(*YQLProvider); !ok {
+		t.Fatalf("Default provider should be a YQLProvider, but is %T", provider)
+	}
+}
+
+func TestNewProviderReturnsErrorForUnknownProvider(t *testing.T) {
+	_, err := NewProvider("foo", nil)
+
+	if _, ok := err.(*UnknownProviderError); !ok {
+		t.Fatalf("NewProvider should return UnknownProviderError, but returned %v", err)
+	}
+}
+
+func TestRegisterProviderMakesProviderAvailableByName(t *testing.T) {
+	RegisterProvider("fake", func(client *http.Client) (Provider, error) { return &fakeProvider{}, nil })
+	defer delete(providers, "fake")
+
+	provider, err := NewProvider("fake", nil)
+
+	if err != nil {
+		t.Fatalf("NewProvider should return registered provider, but returned error: %v", err)
+	}
+
+	if _, ok := provider.(*fakeProvider); !ok {
+		t.Fatalf("Registered provider should be returned, but %T was returned", provider)
+	}
+}
+
+func TestUnknownProviderErrorListsAvailableProviders(t *testing.T) {
+	err := &UnknownProviderError{Name: "foo"}
+
+	if msg := err.Error(); !regexp.MustCompile("Provider 'foo' is not available. Available providers are: .*yql").MatchString(msg) {
+		t.Fatalf("Error message should list available providers, but is %s", msg)
+	}
+}
@@ -0,0 +1,102 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type Decoder interface {
+	Decode(raw string) (*ExchangesResult, error)
+}
+
+type ReplayProvider struct {
+	Dir     string
+	Decoder Decoder
+}
+
+type RecordingProvider struct {
+	Provider Provider
+	Dir      string
+}
+
+type FixtureNotFoundError struct {
+	Path string
+}
+
+func (provider *ReplayProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	path := fixturePath(provider.Dir, symbols)
+	raw, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil, &FixtureNotFoundError{Path: path}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := provider.Decoder.Decode(strings.TrimSpace(string(raw)))
+
+	if err != nil {
+		return result, err
+	}
+
+	result.complete(symbols)
+
+	return result, nil
+}
+
+func (provider *RecordingProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	result, err := provider.Provider.Quotes(ctx, symbols)
+
+	if result == nil || result.rawResult == "" {
+		return result, err
+	}
+
+	if recordErr := provider.record(symbols, result.rawResult); recordErr != nil {
+		log.Printf("Could not record fixture for '%s': %v\n", strings.Join(symbols, ","), recordErr)
+	}
+
+	return result, err
+}
+
+func (provider *RecordingProvider) record(symbols []string, raw string) error {
+	if err := os.MkdirAll(provider.Dir, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fixturePath(provider.Dir, symbols), []byte(raw+"\n"), 0644)
+}
+
+func FixtureKey(symbols []string) string {
+	unique := make(map[string]bool)
+	keys := make([]string, 0, len(symbols))
+
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+		if !unique[symbol] {
+			unique[symbol] = true
+			keys = append(keys, symbol)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return url.QueryEscape(strings.Join(keys, ","))
+}
+
+func fixturePath(dir string, symbols []string) string {
+	return filepath.Join(dir, FixtureKey(symbols)+".raw")
+}
+
+func (err *FixtureNotFoundError) Error() string {
+	return fmt.Sprintf("There is no recorded response on %s.", err.Path)
+}
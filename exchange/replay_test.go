@@ -0,0 +1,114 @@
+package exchange
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const recordedQuote = "{\"query\":{\"results\":{\"quote\":{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"PercentChange\":\"-0.91%\",\"Change\":\"-172.98\",\"LastTradeDate\":\"4/14/2017\",\"LastTradeTime\":\"3:15pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"70000.0000\",\"LastTradePriceOnly\":\"78000.0000\"}}}}"
+
+type rawProvider struct {
+	raw   string
+	calls int
+}
+
+func (provider *rawProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	provider.calls++
+	result := &ExchangesResult{rawResult: provider.raw}
+
+	return result, result.Parse()
+}
+
+func TestFixtureKeyIsIndependentOfOrderCaseAndDuplicates(t *testing.T) {
+	expected := "GOOGL%2C%5EN225"
+
+	if actual := FixtureKey([]string{"googl", "^N225", " GOOGL"}); actual != expected {
+		t.Fatalf("FixtureKey should be %s, but is %s", expected, actual)
+	}
+}
+
+func TestRecordingProviderKeepsLiveResultsWhenRecordingFails(t *testing.T) {
+	file, _ := ioutil.TempFile("", "exchange_fetcher")
+	file.Close()
+	defer os.Remove(file.Name())
+
+	live := &rawProvider{raw: recordedQuote}
+	recorder := &RecordingProvider{Provider: live, Dir: filepath.Join(file.Name(), "fixtures")}
+
+	result, err := recorder.Quotes(context.Background(), []string{"^N225"})
+
+	if err != nil {
+		t.Fatalf("Recording provider should not return recording errors, but returned: %v", err)
+	}
+
+	if _, ok := result.Exchanges["^n225"]; !ok {
+		t.Fatalf("Recording provider should return live exchanges, but returned %v", result.Exchanges)
+	}
+}
+
+func TestRecordingProviderSavesRawResultForReplay(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "exchange_fetcher")
+	defer os.RemoveAll(dir)
+
+	live := &rawProvider{raw: recordedQuote}
+	recorder := &RecordingProvider{Provider: live, Dir: filepath.Join(dir, "fixtures")}
+
+	if _, err := recorder.Quotes(context.Background(), []string{"^N225"}); err != nil {
+		t.Fatalf("Recording provider should return live results, but returned error: %v", err)
+	}
+
+	replay := &ReplayProvider{Dir: filepath.Join(dir, "fixtures"), Decoder: &YQLProvider{}}
+
+	result, err := replay.Quotes(context.Background(), []string{"^n225"})
+
+	if err != nil {
+		t.Fatalf("Replay provider should return recorded results, but returned error: %v", err)
+	}
+
+	if result.rawResult != recordedQuote {
+		t.Fatalf("Replayed raw result should be %v, but is %v", recordedQuote, result.rawResult)
+	}
+
+	if _, ok := result.Exchanges["^n225"]; !ok {
+		t.Fatalf("Replayed exchanges should be parsed, but are %v", result.Exchanges)
+	}
+
+	if live.calls != 1 {
+		t.Fatalf("Live provider should be called %d time, but was called %d times", 1, live.calls)
+	}
+}
+
+func TestReplayProviderReturnsErrorForMissingFixture(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "exchange_fetcher")
+	defer os.RemoveAll(dir)
+
+	replay := &ReplayProvider{Dir: dir, Decoder: &YQLProvider{}}
+
+	_, err := replay.Quotes(context.Background(), []string{"AAPL"})
+
+	if _, ok := err.(*FixtureNotFoundError); !ok {
+		t.Fatalf("Replay provider should return FixtureNotFoundError, but returned %v", err)
+	}
+}
+
+func TestReplayProviderDecodesCSVFixtures(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "exchange_fetcher")
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "AAPL.raw"), []byte("AAPL,Apple Inc.,155.39,154.18,153.48,+1.91,+1.24%,10/5/2017,4:00pm\n"), 0644)
+
+	replay := &ReplayProvider{Dir: dir, Decoder: &CSVProvider{}}
+
+	result, err := replay.Quotes(context.Background(), []string{"AAPL"})
+
+	if err != nil {
+		t.Fatalf("Replay provider should return recorded results, but returned error: %v", err)
+	}
+
+	if price := result.Exchanges["AAPL"].Price.String(); price != "155.39" {
+		t.Fatalf("Replayed price should be %s, but is %s", "155.39", price)
+	}
+}
@@ -0,0 +1,148 @@
+package exchange
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var DefaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type RetryPolicy struct {
+	MaxAttempts          int
+	BaseDelay, MaxDelay  time.Duration
+	Jitter               float64
+	RetryableStatusCodes []int
+}
+
+type RetryTransport struct {
+	Base   http.RoundTripper
+	Policy RetryPolicy
+	sleep  func(ctx context.Context, delay time.Duration) error
+	random func() float64
+}
+
+func (transport *RetryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	base := transport.Base
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	for attempt := 1; ; attempt++ {
+		response, err := base.RoundTrip(request)
+
+		if attempt >= transport.Policy.MaxAttempts || !transport.shouldRetry(request, response, err) {
+			return response, err
+		}
+
+		delay, ok := transport.delay(request.Context(), attempt, response)
+
+		if !ok {
+			return response, err
+		}
+
+		if response != nil {
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+		}
+
+		if err := transport.wait(request.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (transport *RetryTransport) shouldRetry(request *http.Request, response *http.Response, err error) bool {
+	if request.Context().Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	for _, code := range transport.Policy.RetryableStatusCodes {
+		if response.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (transport *RetryTransport) delay(ctx context.Context, attempt int, response *http.Response) (time.Duration, bool) {
+	if response != nil {
+		if delay, ok := retryAfter(response.Header.Get("Retry-After")); ok {
+			if transport.Policy.MaxDelay > 0 && delay > transport.Policy.MaxDelay {
+				return 0, false
+			}
+
+			if deadline, ok := ctx.Deadline(); ok && delay > time.Until(deadline) {
+				return 0, false
+			}
+
+			return delay, true
+		}
+	}
+
+	delay := transport.Policy.BaseDelay << uint(attempt-1)
+
+	if delay <= 0 || (transport.Policy.MaxDelay > 0 && delay > transport.Policy.MaxDelay) {
+		delay = transport.Policy.MaxDelay
+	}
+
+	random := transport.random
+
+	if random == nil {
+		random = rand.Float64
+	}
+
+	return delay - time.Duration(float64(delay)*transport.Policy.Jitter*random()), true
+}
+
+func (transport *RetryTransport) wait(ctx context.Context, delay time.Duration) error {
+	if transport.sleep != nil {
+		return transport.sleep(ctx, delay)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,249 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingTransport struct {
+	failures, calls int
+}
+
+func (transport *failingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.calls++
+
+	if transport.calls <= transport.failures {
+		return nil, errors.New("connection reset by peer")
+	}
+
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func flakyServer(failures int, status int, header http.Header) (*httptest.Server, *int) {
+	calls := 0
+
+	testServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				if calls <= failures {
+					for key, values := range header {
+						w.Header()[key] = values
+					}
+
+					w.WriteHeader(status)
+					fmt.Fprintln(w, "Service Unavailable")
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintln(w, "{\"ok\":true}")
+			},
+		),
+	)
+
+	return testServer, &calls
+}
+
+func retryClient(policy RetryPolicy, base http.RoundTripper, delays *[]time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &RetryTransport{
+			Base:   base,
+			Policy: policy,
+			sleep: func(ctx context.Context, delay time.Duration) error {
+				*delays = append(*delays, delay)
+				return nil
+			},
+			random: func() float64 { return 1.0 },
+		},
+	}
+}
+
+func TestRetryTransportRetriesRetryableStatusUntilSuccess(t *testing.T) {
+	testServer, calls := flakyServer(2, http.StatusServiceUnavailable, nil)
+	defer testServer.Close()
+
+	var delays []time.Duration
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: 0.5, RetryableStatusCodes: DefaultRetryableStatusCodes}
+
+	result, err := Fetch(context.Background(), retryClient(policy, nil, &delays), testServer.URL)
+
+	if err != nil {
+		t.Fatalf("Fetch should succeed after retries, but returned error: %v", err)
+	}
+
+	if result.rawResult != "{\"ok\":true}" {
+		t.Fatalf("Raw result should be from successful attempt, but is %v", result.rawResult)
+	}
+
+	if *calls != 3 {
+		t.Fatalf("Server should be called %d times, but was called %d times", 3, *calls)
+	}
+
+	expected := []time.Duration{50 * time.Millisecond, 100 * time.Millisecond}
+
+	if len(delays) != len(expected) || delays[0] != expected[0] || delays[1] != expected[1] {
+		t.Fatalf("Backoff delays with jitter should be %v, but were %v", expected, delays)
+	}
+}
+
+func TestRetryTransportStopsAfterMaxAttempts(t *testing.T) {
+	testServer, calls := flakyServer(5, http.StatusBadGateway, nil)
+	defer testServer.Close()
+
+	var delays []time.Duration
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 1500 * time.Millisecond, RetryableStatusCodes: DefaultRetryableStatusCodes}
+
+	client := retryClient(policy, nil, &delays)
+	response, err := client.Get(testServer.URL)
+
+	if err != nil {
+		t.Fatalf("Last response should be returned after max attempts, but returned error: %v", err)
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Last response status should be %d, but is %d", http.StatusBadGateway, response.StatusCode)
+	}
+
+	if *calls != 3 {
+		t.Fatalf("Server should be called %d times, but was called %d times", 3, *calls)
+	}
+
+	if delays[1] != 1500*time.Millisecond {
+		t.Fatalf("Backoff delay should be capped on %v, but was %v", 1500*time.Millisecond, delays[1])
+	}
+}
+
+func TestRetryTransportDoesNotRetryOtherStatusCodes(t *testing.T) {
+	testServer, calls := flakyServer(1, http.StatusNotFound, nil)
+	defer testServer.Close()
+
+	var delays []time.Duration
+	policy := RetryPolicy{MaxAttempts: 3, RetryableStatusCodes: DefaultRetryableStatusCodes}
+
+	response, _ := retryClient(policy, nil, &delays).Get(testServer.URL)
+	response.Body.Close()
+
+	if *calls != 1 {
+		t.Fatalf("Server should be called %d time, but was called %d times", 1, *calls)
+	}
+}
+
+func TestRetryTransportHonorsRetryAfter(t *testing.T) {
+	testServer, _ := flakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"7"}})
+	defer testServer.Close()
+
+	var delays []time.Duration
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Second, RetryableStatusCodes: DefaultRetryableStatusCodes}
+
+	if _, err := Fetch(context.Background(), retryClient(policy, nil, &delays), testServer.URL); err != nil {
+		t.Fatalf("Fetch should succeed after retry, but returned error: %v", err)
+	}
+
+	if len(delays) != 1 || delays[0] != 7*time.Second {
+		t.Fatalf("Retry delay should follow Retry-After header of %v, but was %v", 7*time.Second, delays)
+	}
+}
+
+func TestRetryTransportReturnsResponseWhenRetryAfterIsTooLong(t *testing.T) {
+	results := []struct {
+		maxDelay time.Duration
+		timeout  time.Duration
+	}{
+		{5 * time.Second, time.Minute},
+		{time.Minute, 5 * time.Second},
+	}
+
+	for _, r := range results {
+		testServer, calls := flakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"7"}})
+
+		var delays []time.Duration
+		policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: r.maxDelay, RetryableStatusCodes: DefaultRetryableStatusCodes}
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+		request, _ := http.NewRequest(http.MethodGet, testServer.URL, nil)
+		response, err := retryClient(policy, nil, &delays).Do(request.WithContext(ctx))
+
+		if err != nil {
+			t.Fatalf("Upstream response should be returned, but returned error: %v", err)
+		}
+
+		response.Body.Close()
+		cancel()
+		testServer.Close()
+
+		if response.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("Response status should be %d, but is %d", http.StatusTooManyRequests, response.StatusCode)
+		}
+
+		if *calls != 1 || len(delays) != 0 {
+			t.Fatalf("Server should be called once without waiting, but was called %d times after %v", *calls, delays)
+		}
+	}
+}
+
+func TestRetryTransportRetriesConnectionErrors(t *testing.T) {
+	testServer, _ := flakyServer(0, http.StatusOK, nil)
+	defer testServer.Close()
+
+	var delays []time.Duration
+	base := &failingTransport{failures: 2}
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	if _, err := Fetch(context.Background(), retryClient(policy, base, &delays), testServer.URL); err != nil {
+		t.Fatalf("Fetch should succeed after connection errors, but returned error: %v", err)
+	}
+
+	if base.calls != 3 {
+		t.Fatalf("Transport should be called %d times, but was called %d times", 3, base.calls)
+	}
+}
+
+func TestRetryTransportStopsWaitingWhenContextIsCancelled(t *testing.T) {
+	testServer, calls := flakyServer(5, http.StatusServiceUnavailable, nil)
+	defer testServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := &http.Client{
+		Transport: &RetryTransport{
+			Policy: RetryPolicy{MaxAttempts: 5, BaseDelay: time.Minute, RetryableStatusCodes: DefaultRetryableStatusCodes},
+		},
+	}
+
+	if _, err := Fetch(ctx, client, testServer.URL); err == nil {
+		t.Fatal("Fetch should fail when context expires while waiting for retry, but nothing happened.")
+	}
+
+	if *calls != 1 {
+		t.Fatalf("Server should be called %d time, but was called %d times", 1, *calls)
+	}
+}
+
+func TestRetryAfterParsesSecondsAndDates(t *testing.T) {
+	results := []struct {
+		header string
+		exp    time.Duration
+		ok     bool
+	}{
+		{header: "", ok: false},
+		{header: "3", exp: 3 * time.Second, ok: true},
+		{header: "Wed, 21 Oct 2015 07:28:00 GMT", exp: 0, ok: true},
+		{header: "soon", ok: false},
+	}
+
+	for _, r := range results {
+		if delay, ok := retryAfter(r.header); delay != r.exp || ok != r.ok {
+			t.Fatalf("retryAfter(%q) should return %v, %v, but returned %v, %v", r.header, r.exp, r.ok, delay, ok)
+		}
+	}
+}
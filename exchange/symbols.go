@@ -0,0 +1,65 @@
+package exchange
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const DefaultSymbolPattern = `^\^?[A-Za-z0-9][A-Za-z0-9.=\-]{0,19}$`
+
+var symbolGrammar = regexp.MustCompile(DefaultSymbolPattern)
+
+type InvalidSymbolError struct {
+	Symbols []string
+}
+
+func SetSymbolPattern(pattern string) error {
+	grammar, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return fmt.Errorf("Symbol pattern '%s' is not a valid regular expression: %v", pattern, err)
+	}
+
+	symbolGrammar = grammar
+
+	return nil
+}
+
+func ValidateSymbols(symbols []string) error {
+	if _, invalid := partitionSymbols(symbols); len(invalid) > 0 {
+		return &InvalidSymbolError{Symbols: invalid}
+	}
+
+	return nil
+}
+
+func FilterSymbols(symbols []string) (valid []string, invalid map[string]error) {
+	valid, rejected := partitionSymbols(symbols)
+
+	for _, symbol := range rejected {
+		if invalid == nil {
+			invalid = make(map[string]error)
+		}
+
+		invalid[symbol] = &InvalidSymbolError{Symbols: []string{symbol}}
+	}
+
+	return valid, invalid
+}
+
+func partitionSymbols(symbols []string) (valid, invalid []string) {
+	for _, symbol := range symbols {
+		if symbolGrammar.MatchString(symbol) {
+			valid = append(valid, symbol)
+		} else {
+			invalid = append(invalid, symbol)
+		}
+	}
+
+	return valid, invalid
+}
+
+func (err *InvalidSymbolError) Error() string {
+	return fmt.Sprintf("Symbols do not match the accepted format: '%s'.", strings.Join(err.Symbols, "', '"))
+}
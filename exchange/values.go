@@ -0,0 +1,72 @@
+package exchange
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+type nullableValue interface {
+	json.Unmarshaler
+	valid() bool
+}
+
+type nullableString struct {
+	Value string
+	Valid bool
+}
+
+type numericString struct {
+	Value Decimal
+	Valid bool
+}
+
+func (value *nullableString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*value = nullableString{}
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &value.Value); err != nil {
+		return err
+	}
+
+	value.Valid = true
+
+	return nil
+}
+
+func (value *nullableString) valid() bool {
+	return value.Valid
+}
+
+func (value *numericString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*value = numericString{}
+		return nil
+	}
+
+	var text string
+
+	if err := json.Unmarshal(data, &text); err != nil {
+		var number json.Number
+
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+
+		text = number.String()
+
+		if float, err := number.Float64(); err == nil && strings.ContainsAny(text, "eE") {
+			text = strconv.FormatFloat(float, 'f', -1, 64)
+		}
+	}
+
+	*value = numericString{Value: parseDecimal(text), Valid: true}
+
+	return nil
+}
+
+func (value *numericString) valid() bool {
+	return value.Valid
+}
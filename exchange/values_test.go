@@ -0,0 +1,60 @@
+package exchange
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNullableStringUnmarshalJSON(t *testing.T) {
+	results := []struct {
+		body  string
+		exp   nullableString
+		fails bool
+	}{
+		{body: "\"4:00pm\"", exp: nullableString{Value: "4:00pm", Valid: true}},
+		{body: "\"\"", exp: nullableString{Value: "", Valid: true}},
+		{body: "null", exp: nullableString{}},
+		{body: "12", fails: true},
+	}
+
+	for _, r := range results {
+		var actual nullableString
+		err := json.Unmarshal([]byte(r.body), &actual)
+
+		if (err != nil) != r.fails {
+			t.Fatalf("Unmarshaling %s should fail: %v, but error was %v", r.body, r.fails, err)
+		}
+
+		if !r.fails && actual != r.exp {
+			t.Fatalf("Unmarshaling %s should result in %v, but result was %v", r.body, r.exp, actual)
+		}
+	}
+}
+
+func TestNumericStringUnmarshalJSON(t *testing.T) {
+	results := []struct {
+		body  string
+		exp   numericString
+		fails bool
+	}{
+		{body: "\"155.3900\"", exp: numericString{Value: NewDecimal(1553900, 4), Valid: true}},
+		{body: "155.39", exp: numericString{Value: NewDecimal(15539, 2), Valid: true}},
+		{body: "1.5539e2", exp: numericString{Value: NewDecimal(15539, 2), Valid: true}},
+		{body: "\"-\"", exp: numericString{Valid: true}},
+		{body: "null", exp: numericString{}},
+		{body: "[]", fails: true},
+	}
+
+	for _, r := range results {
+		var actual numericString
+		err := json.Unmarshal([]byte(r.body), &actual)
+
+		if (err != nil) != r.fails {
+			t.Fatalf("Unmarshaling %s should fail: %v, but error was %v", r.body, r.fails, err)
+		}
+
+		if !r.fails && actual != r.exp {
+			t.Fatalf("Unmarshaling %s should result in %v, but result was %v", r.body, r.exp, actual)
+		}
+	}
+}
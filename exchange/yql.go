@@ -0,0 +1,241 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const yqlChunkSize = 50
+
+const (
+	baseURL      = "https://query.yahooapis.com/v1/public/yql"
+	yqlQuery     = `select * from yahoo.finance.quotes where symbol in ("%s")`
+	yqlTablesEnv = "store://datatables.org/alltableswithkeys"
+)
+
+type YQLProvider struct {
+	Client *http.Client
+}
+
+type yqlResponse struct {
+	Query struct {
+		Results *struct {
+			Quote yqlQuotes `json:"quote"`
+		} `json:"results"`
+	} `json:"query"`
+}
+
+type yqlQuotes []json.RawMessage
+
+type yqlQuote struct {
+	symbol, Symbol, Name                                nullableString
+	PercentChange, Change, LastTradeDate, LastTradeTime nullableString
+	StockExchange, Currency, MarketCapitalization       nullableString
+	LastTradePriceOnly, PreviousClose, Open             numericString
+	DaysHigh, DaysLow, YearHigh, YearLow                numericString
+	Volume, AverageDailyVolume                          numericString
+}
+
+type yqlField struct {
+	name  string
+	value nullableValue
+}
+
+func (provider *YQLProvider) Quotes(ctx context.Context, symbols []string) (*ExchangesResult, error) {
+	valid, invalid := partitionSymbols(symbols)
+
+	if len(valid) == 0 {
+		return nil, &InvalidSymbolError{Symbols: invalid}
+	}
+
+	requestURL, err := BuildURL(valid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := Fetch(ctx, provider.Client, requestURL, jsonContentTypes...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = provider.Decode(result.rawResult)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.complete(valid)
+
+	for _, symbol := range invalid {
+		result.setError(symbol, &InvalidSymbolError{Symbols: []string{symbol}})
+	}
+
+	return result, nil
+}
+
+func (provider *YQLProvider) ChunkSize() int {
+	return yqlChunkSize
+}
+
+func (provider *YQLProvider) Decode(raw string) (*ExchangesResult, error) {
+	result := &ExchangesResult{rawResult: raw}
+
+	return result, result.Parse()
+}
+
+func BuildURL(indexes []string) (string, error) {
+	if err := ValidateSymbols(indexes); err != nil {
+		return "", err
+	}
+
+	query := fmt.Sprintf(yqlQuery, strings.Join(indexes, ","))
+
+	return fmt.Sprintf(
+		"%s?q=%s&format=json&env=%s", baseURL, escapeQuery(query), escapeQuery(yqlTablesEnv),
+	), nil
+}
+
+func escapeQuery(value string) string {
+	return strings.Replace(url.QueryEscape(value), "+", "%20", -1)
+}
+
+func (ex *ExchangesResult) Parse() error {
+	ex.Exchanges = make(map[string]Exchange)
+
+	var response yqlResponse
+
+	if err := json.Unmarshal([]byte(ex.rawResult), &response); err != nil || response.Query.Results == nil {
+		return &malformedJSONError{"There was a problem when parsing JSON response."}
+	}
+
+	for _, rawQuote := range response.Query.Results.Quote {
+		exchange, err := decodeYQLQuote(rawQuote)
+
+		if err == nil {
+			ex.Exchanges[exchange.Symbol] = exchange
+		} else if fieldErr, ok := err.(*FieldError); ok && fieldErr.Symbol != "" {
+			ex.setError(fieldErr.Symbol, err)
+		}
+	}
+
+	return nil
+}
+
+func (quotes *yqlQuotes) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*quotes = nil
+	case bytes.HasPrefix(data, []byte("[")):
+		var list []json.RawMessage
+
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+
+		*quotes = list
+	default:
+		*quotes = yqlQuotes{json.RawMessage(data)}
+	}
+
+	return nil
+}
+
+func decodeYQLQuote(rawQuote json.RawMessage) (Exchange, error) {
+	var fields map[string]json.RawMessage
+	var quote yqlQuote
+
+	if err := json.Unmarshal(rawQuote, &fields); err != nil {
+		return Exchange{}, &FieldError{Field: "quote", Err: err}
+	}
+
+	json.Unmarshal(fields["symbol"], &quote.symbol)
+
+	for _, field := range append(quote.fields(), quote.optionalFields()...) {
+		value, ok := fields[field.name]
+
+		if !ok {
+			continue
+		}
+
+		if err := json.Unmarshal(value, field.value); err != nil {
+			return Exchange{}, &FieldError{Symbol: quote.identity(), Field: field.name, Err: err}
+		}
+	}
+
+	for _, field := range quote.fields() {
+		if !field.value.valid() {
+			return Exchange{}, &FieldError{Symbol: quote.identity(), Field: field.name}
+		}
+	}
+
+	exchange := Exchange{
+		Name:           quote.Name.Value,
+		Symbol:         quote.Symbol.Value,
+		PercentChange:  parsePercent(quote.PercentChange.Value),
+		ChangeInPoints: parseDecimal(quote.Change.Value),
+		Price:          quote.LastTradePriceOnly.Value,
+		PreviousClose:  quote.PreviousClose.Value,
+		OpenPrice:      quote.Open.Value,
+		LastTradeDate:  quote.LastTradeDate.Value,
+		LastTradeTime:  quote.LastTradeTime.Value,
+		DayHigh:        quote.DaysHigh.Value,
+		DayLow:         quote.DaysLow.Value,
+		YearHigh:       quote.YearHigh.Value,
+		YearLow:        quote.YearLow.Value,
+		Volume:         quote.Volume.Value,
+		AverageVolume:  quote.AverageDailyVolume.Value,
+		MarketCap:      parseAbbreviated(quote.MarketCapitalization.Value),
+		Currency:       quote.Currency.Value,
+		StockExchange:  quote.StockExchange.Value,
+	}
+
+	exchange.deriveChanges()
+	exchange.resolveLastTrade(quote.StockExchange.Value)
+
+	return exchange, nil
+}
+
+func (quote *yqlQuote) fields() []yqlField {
+	return []yqlField{
+		{"Symbol", &quote.Symbol},
+		{"Name", &quote.Name},
+	}
+}
+
+func (quote *yqlQuote) optionalFields() []yqlField {
+	return []yqlField{
+		{"LastTradePriceOnly", &quote.LastTradePriceOnly},
+		{"PreviousClose", &quote.PreviousClose},
+		{"Open", &quote.Open},
+		{"LastTradeDate", &quote.LastTradeDate},
+		{"LastTradeTime", &quote.LastTradeTime},
+		{"PercentChange", &quote.PercentChange},
+		{"Change", &quote.Change},
+		{"StockExchange", &quote.StockExchange},
+		{"Currency", &quote.Currency},
+		{"MarketCapitalization", &quote.MarketCapitalization},
+		{"DaysHigh", &quote.DaysHigh},
+		{"DaysLow", &quote.DaysLow},
+		{"YearHigh", &quote.YearHigh},
+		{"YearLow", &quote.YearLow},
+		{"Volume", &quote.Volume},
+		{"AverageDailyVolume", &quote.AverageDailyVolume},
+	}
+}
+
+func (quote *yqlQuote) identity() string {
+	if quote.Symbol.Valid {
+		return quote.Symbol.Value
+	}
+
+	return quote.symbol.Value
+}
@@ -0,0 +1,394 @@
+package exchange
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildURLWithOneIndex(t *testing.T) {
+	index := []string{"^BVSP"}
+	expected := "https://query.yahooapis.com/v1/public/yql?q=select%20%2A%20from%20yahoo.finance.quotes%20where%20symbol%20in%20%28%22%5EBVSP%22%29&format=json&env=store%3A%2F%2Fdatatables.org%2Falltableswithkeys"
+
+	actual, err := BuildURL(index)
+
+	if err != nil {
+		t.Fatalf("BuildURL should return URL, but returned error: %v", err)
+	}
+
+	if actual != expected {
+		t.Fatalf("Expected URL to be %s, is %s", expected, actual)
+	}
+}
+
+func TestBuildURLWithMoreThanOneIndex(t *testing.T) {
+	indexes := []string{"^BVSP", "GOOGL"}
+	expected := "https://query.yahooapis.com/v1/public/yql?q=select%20%2A%20from%20yahoo.finance.quotes%20where%20symbol%20in%20%28%22%5EBVSP%2CGOOGL%22%29&format=json&env=store%3A%2F%2Fdatatables.org%2Falltableswithkeys"
+
+	actual, err := BuildURL(indexes)
+
+	if err != nil {
+		t.Fatalf("BuildURL should return URL, but returned error: %v", err)
+	}
+
+	if actual != expected {
+		t.Fatalf("Expected URL to be %s, is %s", expected, actual)
+	}
+}
+
+func TestBuildURLEscapesSymbols(t *testing.T) {
+	results := []struct {
+		symbol, encoded string
+	}{
+		{"^GSPC", "%5EGSPC"},
+		{"BRK.B", "BRK.B"},
+		{"EURUSD=X", "EURUSD%3DX"},
+		{"MGLU3.SA", "MGLU3.SA"},
+		{"BRK-B", "BRK-B"},
+	}
+
+	for _, result := range results {
+		actual, err := BuildURL([]string{result.symbol})
+
+		if err != nil {
+			t.Fatalf("BuildURL should accept %s, but returned error: %v", result.symbol, err)
+		}
+
+		if !strings.Contains(actual, "%28%22"+result.encoded+"%22%29") {
+			t.Fatalf("Symbol %s should be encoded as %s, but URL is %s", result.symbol, result.encoded, actual)
+		}
+	}
+}
+
+func TestBuildURLRejectsInvalidSymbols(t *testing.T) {
+	_, err := BuildURL([]string{"AAPL", `GOOGL") or symbol in ("MSFT`, "", "A B"})
+
+	invalidErr, ok := err.(*InvalidSymbolError)
+
+	if !ok {
+		t.Fatalf("BuildURL should return InvalidSymbolError, but returned %v", err)
+	}
+
+	if len(invalidErr.Symbols) != 3 || invalidErr.Symbols[0] != `GOOGL") or symbol in ("MSFT` {
+		t.Fatalf("Error should list every invalid symbol, but lists %v", invalidErr.Symbols)
+	}
+}
+
+func TestYQLProviderDoesNotRequestInvalidSymbols(t *testing.T) {
+	provider := &YQLProvider{}
+
+	result, err := provider.Quotes(context.Background(), []string{`"); drop`})
+
+	if _, ok := err.(*InvalidSymbolError); !ok || result != nil {
+		t.Fatalf("Provider should reject invalid symbols before requesting, but returned %v", err)
+	}
+}
+
+func TestSetSymbolPattern(t *testing.T) {
+	defer SetSymbolPattern(DefaultSymbolPattern)
+
+	if err := SetSymbolPattern("^[A-Z]+$"); err != nil {
+		t.Fatalf("Pattern should be accepted, but returned error: %v", err)
+	}
+
+	if err := ValidateSymbols([]string{"BRK.B"}); err == nil {
+		t.Fatalf("Symbol should be rejected by custom pattern")
+	}
+
+	if err := SetSymbolPattern("[A-Z"); err == nil {
+		t.Fatalf("Invalid pattern should return error")
+	}
+}
+
+func TestFilterSymbols(t *testing.T) {
+	valid, invalid := FilterSymbols([]string{"AAPL", "bad symbol!", "^GSPC"})
+
+	if strings.Join(valid, ",") != "AAPL,^GSPC" {
+		t.Fatalf("Valid symbols should be AAPL,^GSPC, but are %v", valid)
+	}
+
+	if _, ok := invalid["bad symbol!"].(*InvalidSymbolError); !ok || len(invalid) != 1 {
+		t.Fatalf("Invalid symbol should report InvalidSymbolError, but errors are %v", invalid)
+	}
+}
+
+func TestParseForOneIndex(t *testing.T) {
+	jsonResult := "{\"query\":{\"results\":{\"quote\":{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"PercentChange\":\"-0.91%\",\"Change\":\"-172.98\",\"LastTradeDate\":\"4/14/2017\",\"LastTradeTime\":\"3:15pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"70000.0000\",\"LastTradePriceOnly\":\"78000.0000\"}}}}"
+
+	exchangeResult := ExchangesResult{rawResult: jsonResult}
+
+	exchangeResult.Parse()
+
+	for key, exchange := range exchangeResult.Exchanges {
+		if key != "^n225" {
+			t.Fatalf("Exchanges list should have key %s, but it is %s", "^n225", key)
+		}
+
+		if exchange.Name != "Nikkei 225" {
+			t.Fatalf("Exchange name should be %s, is %s", "Nikkei 225", exchange.Name)
+		}
+
+		if exchange.Symbol != "^n225" {
+			t.Fatalf("Exchange symbol should be %s, is %s", "^n225", exchange.Symbol)
+		}
+
+		if exchange.FormattedPercentChange() != "-0.91%" {
+			t.Fatalf("Parsed percent change should be %s, is %s", "-0.91%", exchange.FormattedPercentChange())
+		}
+
+		if exchange.FormattedChange() != "-172.98" {
+			t.Fatalf("Parsed change in points should be %s, is %s", "-172.98", exchange.FormattedChange())
+		}
+
+		if exchange.Price.String() != "78000.0000" {
+			t.Fatalf("Parsed price should be %s, is %s", "78000.0000", exchange.Price)
+		}
+
+		if exchange.LastTradeDate != "4/14/2017" {
+			t.Fatalf("Parsed 'last trade date' should be %s, is %s", "4/14/2017", exchange.LastTradeDate)
+		}
+
+		if exchange.LastTradeTime != "3:15pm" {
+			t.Fatalf("Parsed 'last trade time' should be %s, is %s", "3:15pm", exchange.LastTradeTime)
+		}
+
+		if exchange.PreviousClose.String() != "70000.0000" {
+			t.Fatalf("Parsed 'previous close' should be %s, is %s", "70000.0000", exchange.PreviousClose)
+		}
+
+		if exchange.OpenPrice.String() != "76592.1150" {
+			t.Fatalf("Parsed 'open price' should be %s, is %s", "76592.1150", exchange.OpenPrice)
+		}
+	}
+}
+
+func TestParseWithMissingValueWhenAnyDecimalIsNotParseable(t *testing.T) {
+	jsonResult := "{\"query\":{\"results\":{\"quote\":{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"PercentChange\":\"-0.91%\",\"Change\":\"-172.98\",\"LastTradeDate\":\"4/14/2017\",\"LastTradeTime\":\"3:15pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"-\",\"LastTradePriceOnly\":\"78000.0000\"}}}}"
+
+	exchangeResult := ExchangesResult{rawResult: jsonResult}
+
+	exchangeResult.Parse()
+
+	for key, exchange := range exchangeResult.Exchanges {
+		if key != "^n225" {
+			t.Fatalf("Exchanges list should have key %s, but it is %s", "^n225", key)
+		}
+
+		if exchange.Name != "Nikkei 225" {
+			t.Fatalf("Exchange name should be %s, is %s", "Nikkei 225", exchange.Name)
+		}
+
+		if exchange.Symbol != "^n225" {
+			t.Fatalf("Exchange symbol should be %s, is %s", "^n225", exchange.Symbol)
+		}
+
+		if exchange.FormattedPercentChange() != "-0.91%" {
+			t.Fatalf("Parsed percent change should be %s, is %s", "-0.91%", exchange.FormattedPercentChange())
+		}
+
+		if exchange.FormattedChange() != "-172.98" {
+			t.Fatalf("Parsed change in points should be %s, is %s", "-172.98", exchange.FormattedChange())
+		}
+
+		if exchange.Price.String() != "78000.0000" {
+			t.Fatalf("Parsed price should be %s, is %s", "78000.0000", exchange.Price)
+		}
+
+		if exchange.LastTradeDate != "4/14/2017" {
+			t.Fatalf("Parsed 'last trade date' should be %s, is %s", "4/14/2017", exchange.LastTradeDate)
+		}
+
+		if exchange.LastTradeTime != "3:15pm" {
+			t.Fatalf("Parsed 'last trade time' should be %s, is %s", "3:15pm", exchange.LastTradeTime)
+		}
+
+		if exchange.PreviousClose.Valid() {
+			t.Fatalf("Parsed 'previous close' should be missing, is %s", exchange.PreviousClose)
+		}
+
+		if exchange.OpenPrice.String() != "76592.1150" {
+			t.Fatalf("Parsed 'open price' should be %s, is %s", "76592.1150", exchange.OpenPrice)
+		}
+	}
+}
+
+func TestParseForMoreThanOneIndex(t *testing.T) {
+	exchangeResult := ExchangesResult{
+		rawResult: "{\"query\":{\"results\":{\"quote\":[{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"PercentChange\":\"-0.91%\",\"Change\":\"-172.98\",\"LastTradeDate\":\"4/14/2017\",\"LastTradeTime\":\"3:15pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"70000.0000\",\"LastTradePriceOnly\":\"78000.0000\"},{\"Name\":\"Alphabet Inc.\",\"Symbol\":\"GOOGL\",\"PercentChange\":\"-0.09%\",\"Change\":\"-0.76\",\"LastTradeDate\":\"4/13/2017\",\"LastTradeTime\":\"4:00pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"70000.0000\",\"LastTradePriceOnly\":\"78000.0000\"}]}}}",
+	}
+
+	exchangeResult.Parse()
+
+	expectedList := map[string]Exchange{
+		"^n225": Exchange{Name: "Nikkei 225", Symbol: "^n225", PercentChange: MustParseDecimal("-0.91"), ChangeInPoints: MustParseDecimal("-172.98"), Price: MustParseDecimal("78000.0000"), PreviousClose: MustParseDecimal("70000.0000"), OpenPrice: MustParseDecimal("76592.1150"), LastTradeDate: "4/14/2017", LastTradeTime: "3:15pm", LastTrade: tradeTimeIn("Asia/Tokyo", 2017, time.April, 14, 15, 15)},
+		"GOOGL": Exchange{Name: "Alphabet Inc.", Symbol: "GOOGL", PercentChange: MustParseDecimal("-0.09"), ChangeInPoints: MustParseDecimal("-0.76"), Price: MustParseDecimal("78000.0000"), PreviousClose: MustParseDecimal("70000.0000"), OpenPrice: MustParseDecimal("76592.1150"), LastTradeDate: "4/13/2017", LastTradeTime: "4:00pm", LastTrade: tradeTimeIn("America/New_York", 2017, time.April, 13, 16, 0)},
+	}
+
+	nikkei := exchangeResult.Exchanges["^n225"]
+
+	if nikkei != expectedList["^n225"] {
+		t.Fatalf("Parsed exchanges list should have a %s exchange and it should be equal to %v, but it is %v", "^n225", expectedList["^n225"], nikkei)
+	}
+
+	google := exchangeResult.Exchanges["GOOGL"]
+
+	if google != expectedList["GOOGL"] {
+		t.Fatalf("Parsed exchanges list should have a %s exchange and it should be equal to %v, but it is %v", "GOOGL", expectedList["GOOGL"], google)
+	}
+}
+
+func TestParseForMalformedJSONQuery(t *testing.T) {
+	exchangeResult := ExchangesResult{rawResult: "{\"foo\":{}}"}
+
+	err := exchangeResult.Parse()
+
+	if err == nil {
+		t.Fatal("Parse() with malformed JSON response should return error, but returned nothing.")
+	}
+}
+
+func TestParseForMalformedJSONResults(t *testing.T) {
+	exchangeResult := ExchangesResult{rawResult: "{\"query\":{\"results\":null}}"}
+
+	err := exchangeResult.Parse()
+
+	if err == nil {
+		t.Fatal("Parse() with malformed JSON response should return error, but returned nothing.")
+	}
+}
+
+func TestParseForMalformedJSONQuoteKeyWithOneIndex(t *testing.T) {
+	exchangeResult := ExchangesResult{
+		rawResult: "{\"query\":{\"results\":{\"quote\":{\"Name\":null,\"Symbol\":\"foo\",\"PercentChange\":null,\"Change\":null,\"LastTradeDate\":null,\"LastTradeTime\":null}}}}",
+	}
+
+	exchangeResult.Parse()
+
+	if _, ok := exchangeResult.Errors["foo"].(*FieldError); !ok {
+		t.Fatalf("Parse() with malformed quote should set error for symbol, but errors are %v", exchangeResult.Errors)
+	}
+}
+
+func TestParseForMalformedJSONQuoteKeyWithMoreThanOneIndex(t *testing.T) {
+	exchangeResult := ExchangesResult{
+		rawResult: "{\"query\":{\"results\":{\"quote\":[{\"Name\":null,\"Symbol\":\"foo\",\"PercentChange\":null,\"Change\":null,\"LastTradeDate\":null,\"LastTradeTime\":null},{\"Name\":null,\"Symbol\":\"boo\",\"PercentChange\":null,\"Change\":null,\"LastTradeDate\":null,\"LastTradeTime\":null}]}}}",
+	}
+
+	err := exchangeResult.Parse()
+
+	if err != nil {
+		t.Fatalf("Parse() with malformed quotes should not fail the whole response, but returned %v", err)
+	}
+
+	for _, symbol := range []string{"foo", "boo"} {
+		if _, ok := exchangeResult.Errors[symbol].(*FieldError); !ok {
+			t.Fatalf("Parse() with malformed quote should set error for %s, but errors are %v", symbol, exchangeResult.Errors)
+		}
+	}
+}
+
+func TestParseKeepsQuoteWithNullPriceAsMissing(t *testing.T) {
+	exchangeResult := ExchangesResult{
+		rawResult: "{\"query\":{\"results\":{\"quote\":[{\"symbol\":\"^BVSP\",\"Name\":\"IBOVESPA\",\"Symbol\":\"^BVSP\",\"PercentChange\":\"+0.10%\",\"Change\":\"+76.00\",\"LastTradeDate\":\"10/5/2017\",\"LastTradeTime\":\"5:22pm\",\"Open\":null,\"PreviousClose\":\"76591.00\",\"LastTradePriceOnly\":\"76667.00\"},{\"Name\":\"Alphabet Inc.\",\"Symbol\":\"GOOGL\",\"PercentChange\":\"-0.09%\",\"Change\":\"-0.76\",\"LastTradeDate\":\"4/13/2017\",\"LastTradeTime\":\"4:00pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"70000.0000\",\"LastTradePriceOnly\":\"78000.0000\"}]}}}",
+	}
+
+	exchangeResult.Parse()
+
+	if len(exchangeResult.Errors) != 0 {
+		t.Fatalf("Parse() with null price should not set errors, but errors are %v", exchangeResult.Errors)
+	}
+
+	quote, ok := exchangeResult.Exchanges["^BVSP"]
+
+	if !ok {
+		t.Fatalf("Parse() should keep quote with null price, but parsed %v", exchangeResult.Exchanges)
+	}
+
+	if quote.OpenPrice.Valid() || quote.Price.String() != "76667.00" {
+		t.Fatalf("Null Open should be missing and other prices kept, but quote is %+v", quote)
+	}
+
+	if length := len(exchangeResult.Exchanges); length != 2 {
+		t.Fatalf("Parsed exchanges should have length of %d, but has %d", 2, length)
+	}
+}
+
+func TestParseUsesLowercaseSymbolWhenSymbolFieldIsNull(t *testing.T) {
+	exchangeResult := ExchangesResult{
+		rawResult: "{\"query\":{\"results\":{\"quote\":{\"symbol\":\"FOO\",\"Name\":null,\"Symbol\":null,\"PercentChange\":null,\"Change\":null,\"LastTradeDate\":null,\"LastTradeTime\":null}}}}",
+	}
+
+	exchangeResult.Parse()
+	err := exchangeResult.Errors["FOO"]
+
+	if fieldErr, ok := err.(*FieldError); !ok || fieldErr.Symbol != "FOO" || fieldErr.Field != "Symbol" {
+		t.Fatalf("Parse() should report missing %s on %s, but reported %v", "Symbol", "FOO", err)
+	}
+}
+
+func TestParseDerivesChangesWhenTheyAreMissing(t *testing.T) {
+	exchangeResult := ExchangesResult{
+		rawResult: "{\"query\":{\"results\":{\"quote\":{\"Name\":\"Apple Inc.\",\"Symbol\":\"AAPL\",\"PercentChange\":null,\"LastTradeDate\":\"10/5/2017\",\"LastTradeTime\":\"4:00pm\",\"Open\":\"154.18\",\"PreviousClose\":\"153.48\",\"LastTradePriceOnly\":\"155.39\"}}}}",
+	}
+
+	exchangeResult.Parse()
+	exchange, ok := exchangeResult.Exchanges["AAPL"]
+
+	if !ok {
+		t.Fatalf("Parse() should accept quote without changes, but errors are %v", exchangeResult.Errors)
+	}
+
+	if change := exchange.FormattedChange(); change != "+1.91" {
+		t.Fatalf("Change in points should be derived as %s, but is %s", "+1.91", change)
+	}
+
+	if percent := exchange.FormattedPercentChange(); percent != "+1.24%" {
+		t.Fatalf("Percent change should be derived as %s, but is %s", "+1.24%", percent)
+	}
+}
+
+func TestParseExtendedFields(t *testing.T) {
+	exchangeResult := ExchangesResult{
+		rawResult: "{\"query\":{\"results\":{\"quote\":{\"Name\":\"Apple Inc.\",\"Symbol\":\"AAPL\",\"PercentChange\":\"+1.24%\",\"Change\":\"+1.91\",\"LastTradeDate\":\"10/5/2017\",\"LastTradeTime\":\"4:00pm\",\"Open\":\"154.18\",\"PreviousClose\":\"153.48\",\"LastTradePriceOnly\":\"155.39\",\"DaysLow\":\"154.05\",\"DaysHigh\":\"155.44\",\"YearLow\":\"104.08\",\"YearHigh\":\"164.94\",\"Volume\":\"21283769\",\"AverageDailyVolume\":\"26979300\",\"MarketCapitalization\":\"802.61B\",\"Currency\":\"USD\",\"StockExchange\":\"NMS\"}}}}",
+	}
+
+	exchangeResult.Parse()
+	exchange := exchangeResult.Exchanges["AAPL"]
+
+	results := []struct {
+		field       string
+		actual, exp string
+	}{
+		{"DayLow", exchange.DayLow.String(), "154.05"},
+		{"DayHigh", exchange.DayHigh.String(), "155.44"},
+		{"YearLow", exchange.YearLow.String(), "104.08"},
+		{"YearHigh", exchange.YearHigh.String(), "164.94"},
+		{"Volume", exchange.Volume.String(), "21283769"},
+		{"AverageVolume", exchange.AverageVolume.String(), "26979300"},
+		{"MarketCap", exchange.MarketCap.String(), "802610000000"},
+		{"Currency", exchange.Currency, "USD"},
+		{"StockExchange", exchange.StockExchange, "NMS"},
+	}
+
+	for _, r := range results {
+		if r.actual != r.exp {
+			t.Fatalf("Parsed %s should be %s, is %s", r.field, r.exp, r.actual)
+		}
+	}
+}
+
+func TestParseReportsFieldWithUnexpectedType(t *testing.T) {
+	exchangeResult := ExchangesResult{
+		rawResult: "{\"query\":{\"results\":{\"quote\":{\"Name\":\"Nikkei 225\",\"Symbol\":\"^n225\",\"PercentChange\":\"-0.91%\",\"Change\":\"-172.98\",\"LastTradeDate\":{\"day\":14},\"LastTradeTime\":\"3:15pm\",\"Open\":\"76592.1150\",\"PreviousClose\":\"70000.0000\",\"LastTradePriceOnly\":\"78000.0000\"}}}}",
+	}
+
+	exchangeResult.Parse()
+	err := exchangeResult.Errors["^n225"]
+
+	fieldErr, ok := err.(*FieldError)
+
+	if !ok || fieldErr.Symbol != "^n225" || fieldErr.Field != "LastTradeDate" || fieldErr.Err == nil {
+		t.Fatalf("Parse() should report invalid %s on %s, but reported %v", "LastTradeDate", "^n225", err)
+	}
+}
@@ -0,0 +1,344 @@
+package indices
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultSearchLimit    = 10
+	MaxSearchLimit        = 100
+	maxSuggestions        = 3
+	maxSuggestionDistance = 2
+)
+
+const embeddedDirectory = `symbol,name,exchange,currency,type
+AAPL,Apple Inc.,NASDAQ,USD,equity
+AMZN,Amazon.com Inc.,NASDAQ,USD,equity
+BRK-A,Berkshire Hathaway Inc. Class A,NYSE,USD,equity
+BRK-B,Berkshire Hathaway Inc. Class B,NYSE,USD,equity
+FB,Facebook Inc.,NASDAQ,USD,equity
+GOOG,Alphabet Inc. Class C,NASDAQ,USD,equity
+GOOGL,Alphabet Inc. Class A,NASDAQ,USD,equity
+IBM,International Business Machines Corporation,NYSE,USD,equity
+INTC,Intel Corporation,NASDAQ,USD,equity
+JNJ,Johnson & Johnson,NYSE,USD,equity
+JPM,JPMorgan Chase & Co.,NYSE,USD,equity
+KO,The Coca-Cola Company,NYSE,USD,equity
+MSFT,Microsoft Corporation,NASDAQ,USD,equity
+NFLX,Netflix Inc.,NASDAQ,USD,equity
+NVDA,NVIDIA Corporation,NASDAQ,USD,equity
+ORCL,Oracle Corporation,NYSE,USD,equity
+TSLA,Tesla Inc.,NASDAQ,USD,equity
+V,Visa Inc.,NYSE,USD,equity
+WMT,Walmart Inc.,NYSE,USD,equity
+XOM,Exxon Mobil Corporation,NYSE,USD,equity
+SPY,SPDR S&P 500 ETF Trust,NYSEARCA,USD,etf
+QQQ,Invesco QQQ Trust,NASDAQ,USD,etf
+ABEV3.SA,Ambev S.A.,BOVESPA,BRL,equity
+BBAS3.SA,Banco do Brasil S.A.,BOVESPA,BRL,equity
+ITUB4.SA,Itau Unibanco Holding S.A.,BOVESPA,BRL,equity
+MGLU3.SA,Magazine Luiza S.A.,BOVESPA,BRL,equity
+PETR4.SA,Petroleo Brasileiro S.A. - Petrobras,BOVESPA,BRL,equity
+VALE3.SA,Vale S.A.,BOVESPA,BRL,equity
+^BVSP,IBOVESPA,BOVESPA,BRL,index
+^DJI,Dow Jones Industrial Average,DJI,USD,index
+^FTSE,FTSE 100,FTSE,GBP,index
+^GDAXI,DAX,XETRA,EUR,index
+^GSPC,S&P 500,SNP,USD,index
+^IXIC,NASDAQ Composite,NASDAQ,USD,index
+^N225,Nikkei 225,OSAKA,JPY,index
+EURUSD=X,EUR/USD,CCY,USD,currency
+USDBRL=X,USD/BRL,CCY,BRL,currency
+USDJPY=X,USD/JPY,CCY,JPY,currency
+`
+
+var directoryColumns = []string{"symbol", "name", "exchange", "currency", "type"}
+
+type Listing struct {
+	Symbol, Name, Exchange, Currency, Type string
+}
+
+type Directory []Listing
+
+type DirectoryError struct {
+	Source string
+	err    error
+}
+
+type UnknownSymbolError struct {
+	Symbol      string
+	Suggestions []string
+}
+
+type scoredListing struct {
+	listing Listing
+	score   int
+}
+
+func DefaultDirectory() Directory {
+	directory, err := ReadDirectory(strings.NewReader(embeddedDirectory), "embedded directory")
+
+	if err != nil {
+		panic(err)
+	}
+
+	return directory
+}
+
+func LoadDirectory(path string) (Directory, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, &DirectoryError{Source: path, err: err}
+	}
+
+	defer file.Close()
+
+	return ReadDirectory(file, path)
+}
+
+func ReadDirectory(reader io.Reader, source string) (Directory, error) {
+	records, err := csv.NewReader(reader).ReadAll()
+
+	if err != nil {
+		return nil, &DirectoryError{Source: source, err: err}
+	}
+
+	if len(records) == 0 {
+		return nil, &DirectoryError{Source: source, err: fmt.Errorf("header is missing")}
+	}
+
+	positions := make(map[string]int, len(directoryColumns))
+
+	for position, column := range records[0] {
+		positions[strings.ToLower(strings.TrimSpace(column))] = position
+	}
+
+	for _, column := range directoryColumns {
+		if _, ok := positions[column]; !ok {
+			return nil, &DirectoryError{Source: source, err: fmt.Errorf("column '%s' is missing", column)}
+		}
+	}
+
+	directory := make(Directory, 0, len(records)-1)
+
+	for _, record := range records[1:] {
+		value := func(column string) string {
+			return strings.TrimSpace(record[positions[column]])
+		}
+
+		directory = append(directory, Listing{
+			Symbol:   value("symbol"),
+			Name:     value("name"),
+			Exchange: value("exchange"),
+			Currency: value("currency"),
+			Type:     value("type"),
+		})
+	}
+
+	return directory, nil
+}
+
+func (directory Directory) Search(text string, limit int) []Listing {
+	query := strings.ToLower(strings.TrimSpace(text))
+
+	if query == "" {
+		return nil
+	}
+
+	var matches []scoredListing
+
+	for _, listing := range directory {
+		if score := matchScore(listing, query); score > 0 {
+			matches = append(matches, scoredListing{listing, score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+
+		return matches[i].listing.Symbol < matches[j].listing.Symbol
+	})
+
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	if limit > len(matches) {
+		limit = len(matches)
+	}
+
+	listings := make([]Listing, 0, limit)
+
+	for _, match := range matches[:limit] {
+		listings = append(listings, match.listing)
+	}
+
+	return listings
+}
+
+func (directory Directory) Validate(symbols []string) (known []string, unknown map[string]error) {
+	for _, symbol := range symbols {
+		if directory.contains(symbol) {
+			known = append(known, symbol)
+			continue
+		}
+
+		if unknown == nil {
+			unknown = make(map[string]error)
+		}
+
+		unknown[symbol] = &UnknownSymbolError{Symbol: symbol, Suggestions: directory.suggest(symbol)}
+	}
+
+	return known, unknown
+}
+
+func (directory Directory) contains(symbol string) bool {
+	for _, listing := range directory {
+		if strings.EqualFold(listing.Symbol, symbol) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (directory Directory) suggest(symbol string) []string {
+	var candidates []scoredListing
+	target := strings.ToUpper(symbol)
+
+	for _, listing := range directory {
+		if distance := editDistance(target, strings.ToUpper(listing.Symbol)); distance <= maxSuggestionDistance {
+			candidates = append(candidates, scoredListing{listing, distance})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score < candidates[j].score
+		}
+
+		return candidates[i].listing.Symbol < candidates[j].listing.Symbol
+	})
+
+	var suggestions []string
+
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].listing.Symbol)
+	}
+
+	return suggestions
+}
+
+func editDistance(source, target string) int {
+	from, to := []rune(source), []rune(target)
+	previous := make([]int, len(to)+1)
+	current := make([]int, len(to)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(from); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(to); j++ {
+			cost := 1
+
+			if from[i-1] == to[j-1] {
+				cost = 0
+			}
+
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(to)]
+}
+
+func minimum(values ...int) int {
+	result := values[0]
+
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+
+	return result
+}
+
+func matchScore(listing Listing, query string) int {
+	symbol := strings.ToLower(listing.Symbol)
+	name := strings.ToLower(listing.Name)
+
+	switch {
+	case symbol == query:
+		return 100
+	case strings.HasPrefix(symbol, query), strings.HasPrefix(strings.TrimPrefix(symbol, "^"), query):
+		return 80
+	case hasWordPrefix(name, query):
+		return 60
+	case strings.Contains(name, query), strings.Contains(symbol, query):
+		return 40
+	case isSubsequence(query, name), isSubsequence(query, symbol):
+		return 20
+	}
+
+	return 0
+}
+
+func hasWordPrefix(text, prefix string) bool {
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+
+	return strings.HasPrefix(text, prefix)
+}
+
+func isSubsequence(query, text string) bool {
+	remaining := []rune(query)
+
+	for _, character := range text {
+		if len(remaining) == 0 {
+			break
+		}
+
+		if character == remaining[0] {
+			remaining = remaining[1:]
+		}
+	}
+
+	return len(remaining) == 0
+}
+
+func (err *UnknownSymbolError) Error() string {
+	if len(err.Suggestions) == 0 {
+		return fmt.Sprintf("Symbol '%s' is not in the symbol directory.", err.Symbol)
+	}
+
+	return fmt.Sprintf(
+		"Symbol '%s' is not in the symbol directory. Did you mean: %s?",
+		err.Symbol, strings.Join(err.Suggestions, ", "),
+	)
+}
+
+func (err *DirectoryError) Error() string {
+	return fmt.Sprintf("There was a problem when loading symbol directory from %s: %v", err.Source, err.err)
+}
@@ -0,0 +1,125 @@
+package indices
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultDirectoryIsLoaded(t *testing.T) {
+	directory := DefaultDirectory()
+
+	if len(directory) == 0 {
+		t.Fatal("Embedded directory should have listings, but is empty")
+	}
+
+	for _, listing := range directory {
+		if listing.Symbol == "" || listing.Name == "" || listing.Exchange == "" || listing.Currency == "" || listing.Type == "" {
+			t.Fatalf("Every embedded listing should be complete, but found %v", listing)
+		}
+	}
+}
+
+func TestReadDirectoryWithReorderedColumns(t *testing.T) {
+	csvContent := "Name,Symbol,Type,Currency,Exchange\nApple Inc.,AAPL,equity,USD,NASDAQ\n"
+
+	directory, err := ReadDirectory(strings.NewReader(csvContent), "test")
+
+	if err != nil {
+		t.Fatalf("Directory should be read, but returned error: %v", err)
+	}
+
+	expected := Listing{Symbol: "AAPL", Name: "Apple Inc.", Exchange: "NASDAQ", Currency: "USD", Type: "equity"}
+
+	if len(directory) != 1 || directory[0] != expected {
+		t.Fatalf("Directory should be %v, but is %v", expected, directory)
+	}
+}
+
+func TestReadDirectoryWithMissingColumn(t *testing.T) {
+	_, err := ReadDirectory(strings.NewReader("symbol,name\nAAPL,Apple Inc.\n"), "test")
+
+	if _, ok := err.(*DirectoryError); !ok || !strings.Contains(err.Error(), "'exchange'") {
+		t.Fatalf("Missing column should return DirectoryError, but returned %v", err)
+	}
+}
+
+func TestLoadDirectoryWithMissingFile(t *testing.T) {
+	if _, err := LoadDirectory("missing.csv"); err == nil {
+		t.Fatal("Missing directory file should return error")
+	}
+}
+
+func TestDirectorySearch(t *testing.T) {
+	directory := Directory{
+		{Symbol: "AAPL", Name: "Apple Inc."},
+		{Symbol: "APLE", Name: "Apple Hospitality REIT"},
+		{Symbol: "MSFT", Name: "Microsoft Corporation"},
+		{Symbol: "^GSPC", Name: "S&P 500"},
+		{Symbol: "GOOGL", Name: "Alphabet Inc. Class A"},
+	}
+
+	results := []struct {
+		text     string
+		limit    int
+		expected string
+	}{
+		{"aapl", 0, "AAPL"},
+		{"apple", 0, "AAPL,APLE"},
+		{"apl", 1, "APLE"},
+		{"gspc", 0, "^GSPC"},
+		{"class", 0, "GOOGL"},
+		{"msft corp", 0, "MSFT"},
+		{"zzz", 0, ""},
+		{"mcsft", 0, "MSFT"},
+		{"  ", 0, ""},
+	}
+
+	for _, r := range results {
+		var symbols []string
+
+		for _, listing := range directory.Search(r.text, r.limit) {
+			symbols = append(symbols, listing.Symbol)
+		}
+
+		if actual := strings.Join(symbols, ","); actual != r.expected {
+			t.Fatalf("Search for '%s' should return %s, but returned %s", r.text, r.expected, actual)
+		}
+	}
+}
+
+func TestDirectorySearchCapsLimit(t *testing.T) {
+	request := ParseRequest([]byte("{\"search\":\"a\",\"limit\":100000000000}"))
+	listings := DefaultDirectory().Search(request.Search, request.Limit)
+
+	if len(listings) == 0 || len(listings) > MaxSearchLimit || cap(listings) > MaxSearchLimit {
+		t.Fatalf("Search with huge limit should return up to %d listings, but returned %d", MaxSearchLimit, len(listings))
+	}
+}
+
+func TestDirectoryValidate(t *testing.T) {
+	directory := Directory{
+		{Symbol: "AAPL", Name: "Apple Inc."},
+		{Symbol: "APLE", Name: "Apple Hospitality REIT"},
+		{Symbol: "MSFT", Name: "Microsoft Corporation"},
+	}
+
+	known, unknown := directory.Validate([]string{"aapl", "APPL", "ZZZZZZ", "MSFT"})
+
+	if strings.Join(known, ",") != "aapl,MSFT" {
+		t.Fatalf("Known symbols should be %s, but are %v", "aapl,MSFT", known)
+	}
+
+	appl, ok := unknown["APPL"].(*UnknownSymbolError)
+
+	if !ok || strings.Join(appl.Suggestions, ",") != "AAPL,APLE" {
+		t.Fatalf("Unknown symbol should have suggestions %s, but has %v", "AAPL,APLE", unknown["APPL"])
+	}
+
+	if msg := appl.Error(); msg != "Symbol 'APPL' is not in the symbol directory. Did you mean: AAPL, APLE?" {
+		t.Fatalf("Unknown symbol message should list suggestions, but is %s", msg)
+	}
+
+	if msg := unknown["ZZZZZZ"].Error(); msg != "Symbol 'ZZZZZZ' is not in the symbol directory." {
+		t.Fatalf("Unknown symbol without suggestions should not list them, but message is %s", msg)
+	}
+}
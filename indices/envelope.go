@@ -0,0 +1,132 @@
+package indices
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/docStonehenge/exchange_fetcher/exchange"
+	"time"
+)
+
+const ProtocolVersion = 1
+
+const (
+	StatusOK      = "ok"
+	StatusPartial = "partial"
+	StatusError   = "error"
+)
+
+type Envelope struct {
+	Version     int               `json:"version"`
+	RequestID   string            `json:"request_id,omitempty"`
+	RequestedAt string            `json:"requested_at,omitempty"`
+	Status      string            `json:"status"`
+	Data        json.RawMessage   `json:"data"`
+	Errors      map[string]string `json:"errors,omitempty"`
+	Symbols     map[string]string `json:"symbols,omitempty"`
+}
+
+type LegacyRequestError struct{}
+
+type UnsupportedProtocolError struct {
+	Version int
+}
+
+type RequestedAtError struct {
+	Value string
+}
+
+type EmptyRequestError struct{}
+
+func (request Request) Validate(acceptLegacy bool) error {
+	if !request.Enveloped {
+		if acceptLegacy {
+			return nil
+		}
+
+		return &LegacyRequestError{}
+	}
+
+	if request.Version != ProtocolVersion {
+		return &UnsupportedProtocolError{Version: request.Version}
+	}
+
+	if request.RequestedAt != "" {
+		if _, err := time.Parse(time.RFC3339, request.RequestedAt); err != nil {
+			return &RequestedAtError{Value: request.RequestedAt}
+		}
+	}
+
+	if len(request.Indices) == 0 && request.Search == "" {
+		return &EmptyRequestError{}
+	}
+
+	return nil
+}
+
+func JoinEnvelope(request Request, result *exchange.ExchangesResult, options Options) ([]byte, error) {
+	data, err := join(result, options, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := StatusOK
+
+	if len(result.Errors) > 0 {
+		status = StatusPartial
+
+		if len(result.Exchanges) == 0 {
+			status = StatusError
+		}
+	}
+
+	response := envelope(request, status, data, errorMessages(result.Errors))
+	response.Symbols = result.Resolved
+
+	return json.Marshal(response)
+}
+
+func JoinSearchEnvelope(request Request, listings []Listing) ([]byte, error) {
+	data, err := JoinSearch(request.Search, listings)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(envelope(request, StatusOK, data, nil))
+}
+
+func JoinErrorEnvelope(request Request, requestErr error) ([]byte, error) {
+	return json.Marshal(envelope(request, StatusError, nil, map[string]string{"request": requestErr.Error()}))
+}
+
+func envelope(request Request, status string, data json.RawMessage, errors map[string]string) Envelope {
+	if data == nil {
+		data = json.RawMessage("null")
+	}
+
+	return Envelope{
+		Version:     ProtocolVersion,
+		RequestID:   request.ID,
+		RequestedAt: request.RequestedAt,
+		Status:      status,
+		Data:        data,
+		Errors:      errors,
+	}
+}
+
+func (err *LegacyRequestError) Error() string {
+	return "Requests must be sent inside a versioned envelope."
+}
+
+func (err *UnsupportedProtocolError) Error() string {
+	return fmt.Sprintf("Protocol version %d is not supported. Supported version is %d.", err.Version, ProtocolVersion)
+}
+
+func (err *RequestedAtError) Error() string {
+	return fmt.Sprintf("Value '%s' of requested_at is not an RFC 3339 timestamp.", err.Value)
+}
+
+func (err *EmptyRequestError) Error() string {
+	return "Request data must have indices or search."
+}
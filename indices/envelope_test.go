@@ -0,0 +1,122 @@
+package indices
+
+import (
+	"github.com/docStonehenge/exchange_fetcher/exchange"
+	"strings"
+	"testing"
+)
+
+func TestParseRequestWithEnvelope(t *testing.T) {
+	body := "{\"version\":1,\"request_id\":\"abc-1\",\"requested_at\":\"2017-10-05T16:00:00Z\",\"data\":{\"indices\":[\"AAPL\",\"GOOGL\"],\"fields\":[\"Price\"]}}"
+
+	request := ParseRequest([]byte(body))
+
+	if !request.Enveloped {
+		t.Fatalf("Request should be enveloped, but is not")
+	}
+
+	if request.Version != 1 || request.ID != "abc-1" || request.RequestedAt != "2017-10-05T16:00:00Z" {
+		t.Fatalf("Request envelope should be parsed, but is %+v", request)
+	}
+
+	if indices := strings.Join(request.Indices, ","); indices != "AAPL,GOOGL" {
+		t.Fatalf("Request indices should be AAPL,GOOGL, but are %s", indices)
+	}
+
+	if fields := strings.Join(request.Fields, ","); fields != "Price" {
+		t.Fatalf("Request fields should be Price, but are %s", fields)
+	}
+}
+
+func TestRequestValidate(t *testing.T) {
+	results := []struct {
+		request      Request
+		acceptLegacy bool
+		exp          string
+	}{
+		{Request{}, true, ""},
+		{Request{}, false, "Requests must be sent inside a versioned envelope."},
+		{Request{Enveloped: true, Version: 1, Indices: []string{"AAPL"}}, false, ""},
+		{Request{Enveloped: true, Version: 1, Search: "apple"}, false, ""},
+		{Request{Enveloped: true, Version: 1, Indices: []string{"AAPL"}, RequestedAt: "2017-10-05T16:00:00-04:00"}, false, ""},
+		{ParseRequest([]byte("{\"version\":1}")), false, "Request data must have indices or search."},
+		{ParseRequest([]byte("{\"version\":1,\"data\":{\"fields\":[\"Price\"]}}")), false, "Request data must have indices or search."},
+		{Request{Enveloped: true, Version: 2}, true, "Protocol version 2 is not supported. Supported version is 1."},
+		{Request{Enveloped: true, Version: 1, RequestedAt: "10/5/2017"}, true, "Value '10/5/2017' of requested_at is not an RFC 3339 timestamp."},
+	}
+
+	for _, r := range results {
+		err := r.request.Validate(r.acceptLegacy)
+
+		if r.exp == "" && err != nil {
+			t.Fatalf("Request %+v should be valid, but returned error: %v", r.request, err)
+		}
+
+		if r.exp != "" && (err == nil || err.Error() != r.exp) {
+			t.Fatalf("Request %+v should return error '%s', but returned %v", r.request, r.exp, err)
+		}
+	}
+}
+
+func TestJoinEnvelope(t *testing.T) {
+	request := Request{Enveloped: true, Version: 1, ID: "abc-1", RequestedAt: "2017-10-05T16:00:00Z"}
+	quote := exchange.Exchange{Name: "Foo", Symbol: "F", Price: exchange.MustParseDecimal("30.89")}
+	missing := map[string]error{"BAR": &exchange.MissingSymbolError{Symbol: "BAR"}}
+
+	results := []struct {
+		result *exchange.ExchangesResult
+		exp    string
+	}{
+		{
+			&exchange.ExchangesResult{Exchanges: map[string]exchange.Exchange{"F": quote}},
+			"{\"version\":1,\"request_id\":\"abc-1\",\"requested_at\":\"2017-10-05T16:00:00Z\",\"status\":\"ok\",\"data\":{\"version\":3,\"exchanges\":{\"F\":{\"Symbol\":\"F\",\"Price\":30.89}}}}",
+		},
+		{
+			&exchange.ExchangesResult{Exchanges: map[string]exchange.Exchange{"F": quote}, Errors: missing},
+			"{\"version\":1,\"request_id\":\"abc-1\",\"requested_at\":\"2017-10-05T16:00:00Z\",\"status\":\"partial\",\"data\":{\"version\":3,\"exchanges\":{\"F\":{\"Symbol\":\"F\",\"Price\":30.89}}},\"errors\":{\"BAR\":\"No quote was returned for 'BAR'.\"}}",
+		},
+		{
+			&exchange.ExchangesResult{Exchanges: map[string]exchange.Exchange{"F": quote}, Resolved: map[string]string{"f": "F"}},
+			"{\"version\":1,\"request_id\":\"abc-1\",\"requested_at\":\"2017-10-05T16:00:00Z\",\"status\":\"ok\",\"data\":{\"version\":3,\"exchanges\":{\"F\":{\"Symbol\":\"F\",\"Price\":30.89}}},\"symbols\":{\"f\":\"F\"}}",
+		},
+		{
+			&exchange.ExchangesResult{Exchanges: map[string]exchange.Exchange{}, Errors: missing},
+			"{\"version\":1,\"request_id\":\"abc-1\",\"requested_at\":\"2017-10-05T16:00:00Z\",\"status\":\"error\",\"data\":{\"version\":3,\"exchanges\":{}},\"errors\":{\"BAR\":\"No quote was returned for 'BAR'.\"}}",
+		},
+	}
+
+	for _, r := range results {
+		jsonBody, err := JoinEnvelope(request, r.result, Options{Version: NumericVersion, Fields: []string{"Price"}})
+
+		if err != nil {
+			t.Fatalf("JoinEnvelope should build JSON response, but returned error: %v", err)
+		}
+
+		if string(jsonBody) != r.exp {
+			t.Fatalf("Built JSON response should be equal to %v, but is %v", r.exp, string(jsonBody))
+		}
+	}
+}
+
+func TestJoinErrorEnvelope(t *testing.T) {
+	request := Request{Enveloped: true, Version: 2, ID: "abc-1"}
+	exp := "{\"version\":1,\"request_id\":\"abc-1\",\"status\":\"error\",\"data\":null,\"errors\":{\"request\":\"Protocol version 2 is not supported. Supported version is 1.\"}}"
+
+	jsonBody, _ := JoinErrorEnvelope(request, request.Validate(true))
+
+	if string(jsonBody) != exp {
+		t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
+	}
+}
+
+func TestJoinSearchEnvelope(t *testing.T) {
+	request := Request{Enveloped: true, Version: 1, Search: "apple"}
+	listings := []Listing{{Symbol: "AAPL", Name: "Apple Inc.", Exchange: "NASDAQ", Currency: "USD", Type: "equity"}}
+	exp := "{\"version\":1,\"status\":\"ok\",\"data\":{\"search\":\"apple\",\"results\":[{\"Symbol\":\"AAPL\",\"Name\":\"Apple Inc.\",\"Exchange\":\"NASDAQ\",\"Currency\":\"USD\",\"Type\":\"equity\"}]}}"
+
+	jsonBody, _ := JoinSearchEnvelope(request, listings)
+
+	if string(jsonBody) != exp {
+		t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
+	}
+}
@@ -1,12 +1,69 @@
 package indices
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/docStonehenge/exchange_fetcher/exchange"
+	"reflect"
+	"sort"
 	"strings"
 	"unicode"
 )
 
+const (
+	NameKeyedVersion   = 1
+	SymbolKeyedVersion = 2
+	NumericVersion     = 3
+	DefaultVersion     = SymbolKeyedVersion
+	LatestVersion      = NumericVersion
+)
+
+type Options struct {
+	Version int
+	Fields  []string
+}
+
+type Request struct {
+	Indices     []string
+	Fields      []string
+	Search      string
+	Limit       int
+	Enveloped   bool
+	Version     int
+	ID          string
+	RequestedAt string
+}
+
+type UnsupportedVersionError struct {
+	Version int
+}
+
+type UnknownFieldError struct {
+	Fields  []string
+	Version int
+}
+
+type formattedExchange struct {
+	Name, Symbol                    string
+	Price, PreviousClose, OpenPrice exchange.Decimal
+	PercentChange, ChangeInPoints   string
+	LastTradeDate, LastTradeTime    string
+}
+
+type symbolKeyedResponse struct {
+	Version   int               `json:"version"`
+	Exchanges interface{}       `json:"exchanges"`
+	Errors    map[string]string `json:"errors,omitempty"`
+	Symbols   map[string]string `json:"symbols,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+type searchResponse struct {
+	Search  string    `json:"search"`
+	Results []Listing `json:"results"`
+}
+
 func SplitJSONBody(body []byte) (indices []string) {
 	var idxJSON map[string]interface{}
 
@@ -23,6 +80,46 @@ func SplitJSONBody(body []byte) (indices []string) {
 	return
 }
 
+func ParseRequest(body []byte) Request {
+	var envelope struct {
+		Version     int             `json:"version"`
+		RequestID   string          `json:"request_id"`
+		RequestedAt string          `json:"requested_at"`
+		Data        json.RawMessage `json:"data"`
+	}
+
+	json.Unmarshal(body, &envelope)
+
+	if envelope.Version == 0 && envelope.Data == nil {
+		return parsePayload(body)
+	}
+
+	request := parsePayload(envelope.Data)
+	request.Enveloped = true
+	request.Version = envelope.Version
+	request.ID = envelope.RequestID
+	request.RequestedAt = envelope.RequestedAt
+
+	return request
+}
+
+func parsePayload(body []byte) Request {
+	var lookup struct {
+		Fields []string `json:"fields"`
+		Search string   `json:"search"`
+		Limit  int      `json:"limit"`
+	}
+
+	json.Unmarshal(body, &lookup)
+
+	return Request{
+		Indices: SplitJSONBody(body),
+		Fields:  lookup.Fields,
+		Search:  lookup.Search,
+		Limit:   lookup.Limit,
+	}
+}
+
 func SplitListBody(body string) []string {
 	removeSpacesAndCommas := func(character rune) bool {
 		return unicode.IsSpace(character) ||
@@ -32,8 +129,101 @@ func SplitListBody(body string) []string {
 	return strings.FieldsFunc(body, removeSpacesAndCommas)
 }
 
-func Join(exchanges map[string]exchange.Exchange) ([]byte, error) {
-	body, err := json.Marshal(exchanges)
+func Join(result *exchange.ExchangesResult, options Options) ([]byte, error) {
+	return join(result, options, true)
+}
+
+func join(result *exchange.ExchangesResult, options Options, withSections bool) ([]byte, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	version := options.version()
+	fields, err := options.fields()
+
+	if err != nil {
+		return nil, err
+	}
+
+	views := make(map[string]json.RawMessage, len(result.Exchanges))
+
+	for symbol, exchange := range result.Exchanges {
+		if views[symbol], err = view(exchange, version, fields); err != nil {
+			return nil, err
+		}
+	}
+
+	if version == NameKeyedVersion {
+		return joinByName(result, views)
+	}
+
+	response := symbolKeyedResponse{Version: version, Exchanges: views}
+
+	if withSections {
+		response.Errors = errorMessages(result.Errors)
+		response.Symbols = result.Resolved
+	}
+
+	return json.Marshal(response)
+}
+
+func FieldNames(version int) []string {
+	var exchangeType reflect.Type
+
+	if version == NumericVersion {
+		exchangeType = reflect.TypeOf(exchange.Exchange{})
+	} else {
+		exchangeType = reflect.TypeOf(formattedExchange{})
+	}
+
+	names := make([]string, exchangeType.NumField())
+
+	for i := range names {
+		names[i] = exchangeType.Field(i).Name
+	}
+
+	return names
+}
+
+func JoinError(requestErr error, options Options) ([]byte, error) {
+	version := options.version()
+
+	if version <= NameKeyedVersion || version > LatestVersion {
+		return json.Marshal(map[string]string{"error": requestErr.Error()})
+	}
+
+	return json.Marshal(
+		symbolKeyedResponse{
+			Version:   version,
+			Exchanges: map[string]json.RawMessage{},
+			Error:     requestErr.Error(),
+		},
+	)
+}
+
+func JoinSearch(search string, listings []Listing) ([]byte, error) {
+	if listings == nil {
+		listings = []Listing{}
+	}
+
+	return json.Marshal(searchResponse{Search: search, Results: listings})
+}
+
+func joinByName(result *exchange.ExchangesResult, views map[string]json.RawMessage) ([]byte, error) {
+	response := make(map[string]json.RawMessage, len(result.Exchanges))
+	symbols := make([]string, 0, len(result.Exchanges))
+
+	for symbol := range result.Exchanges {
+		symbols = append(symbols, symbol)
+	}
+
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		response[result.Exchanges[symbol].Name] = views[symbol]
+	}
+
+	body, err := json.Marshal(response)
 
 	if err == nil {
 		return body, nil
@@ -41,3 +231,174 @@ func Join(exchanges map[string]exchange.Exchange) ([]byte, error) {
 
 	return nil, err
 }
+
+func view(quote exchange.Exchange, version int, fields map[string]bool) (json.RawMessage, error) {
+	var value interface{} = quote
+
+	if version != NumericVersion {
+		value = format(quote)
+	}
+
+	body, err := json.Marshal(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return project(body, func(field string, value json.RawMessage) bool {
+		if len(fields) > 0 && !fields[field] && field != "Symbol" {
+			return false
+		}
+
+		return !isExtended(field) || !isEmptyJSON(value)
+	})
+}
+
+func project(body []byte, keep func(field string, value json.RawMessage) bool) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var projected bytes.Buffer
+	projected.WriteByte('{')
+
+	for decoder.More() {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		field, _ := token.(string)
+
+		if !keep(field, value) {
+			continue
+		}
+
+		if projected.Len() > 1 {
+			projected.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(field)
+		projected.Write(key)
+		projected.WriteByte(':')
+		projected.Write(value)
+	}
+
+	projected.WriteByte('}')
+
+	return json.RawMessage(projected.Bytes()), nil
+}
+
+func isExtended(field string) bool {
+	for _, extended := range exchange.ExtendedFields {
+		if field == extended {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isEmptyJSON(value json.RawMessage) bool {
+	text := string(value)
+
+	return text == "null" || text == `""`
+}
+
+func format(quote exchange.Exchange) formattedExchange {
+	return formattedExchange{
+		Name:           quote.Name,
+		Symbol:         quote.Symbol,
+		Price:          quote.Price,
+		PreviousClose:  quote.PreviousClose,
+		OpenPrice:      quote.OpenPrice,
+		PercentChange:  quote.FormattedPercentChange(),
+		ChangeInPoints: quote.FormattedChange(),
+		LastTradeDate:  quote.LastTradeDate,
+		LastTradeTime:  quote.LastTradeTime,
+	}
+}
+
+func errorMessages(errors map[string]error) map[string]string {
+	if len(errors) == 0 {
+		return nil
+	}
+
+	messages := make(map[string]string, len(errors))
+
+	for symbol, err := range errors {
+		messages[symbol] = err.Error()
+	}
+
+	return messages
+}
+
+func (options Options) Validate() error {
+	if version := options.version(); version < NameKeyedVersion || version > LatestVersion {
+		return &UnsupportedVersionError{Version: options.Version}
+	}
+
+	_, err := options.fields()
+
+	return err
+}
+
+func (options Options) fields() (map[string]bool, error) {
+	if len(options.Fields) == 0 {
+		return nil, nil
+	}
+
+	names := make(map[string]string)
+
+	for _, name := range FieldNames(options.version()) {
+		names[strings.ToLower(name)] = name
+	}
+
+	fields := make(map[string]bool, len(options.Fields))
+	var unknown []string
+
+	for _, field := range options.Fields {
+		if name, ok := names[strings.ToLower(strings.TrimSpace(field))]; ok {
+			fields[name] = true
+		} else {
+			unknown = append(unknown, field)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, &UnknownFieldError{Fields: unknown, Version: options.version()}
+	}
+
+	return fields, nil
+}
+
+func (options Options) version() int {
+	if options.Version == 0 {
+		return DefaultVersion
+	}
+
+	return options.Version
+}
+
+func (err *UnknownFieldError) Error() string {
+	return fmt.Sprintf(
+		"Fields '%s' are not available on schema version %d. Available fields are: %s.",
+		strings.Join(err.Fields, "', '"), err.Version, strings.Join(FieldNames(err.Version), ", "),
+	)
+}
+
+func (err *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf(
+		"Response schema version %d is not supported. Supported versions are %d to %d.",
+		err.Version, NameKeyedVersion, LatestVersion,
+	)
+}
@@ -1,6 +1,8 @@
 package indices
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/docStonehenge/exchange_fetcher/exchange"
 	"strings"
 	"testing"
@@ -70,33 +72,264 @@ func TestSplitListBodyCorrectlySeparatesIndicesOnString(t *testing.T) {
 func TestJoinReturnsParsedJSONExchanges(t *testing.T) {
 	exchanges := make(map[string]exchange.Exchange)
 
-	exp := "{\"Bar\":{\"Name\":\"Bar\",\"Symbol\":\"B\",\"Price\":30.89,\"PreviousClose\":40.82,\"OpenPrice\":32.79,\"PercentChange\":\"2%\",\"ChangeInPoints\":\"2.0\",\"LastTradeDate\":\"12/01/2017\",\"LastTradeTime\":\"12:31pm\"},\"Foo\":{\"Name\":\"Foo\",\"Symbol\":\"F\",\"Price\":30.89,\"PreviousClose\":40.82,\"OpenPrice\":32.79,\"PercentChange\":\"2%\",\"ChangeInPoints\":\"2.0\",\"LastTradeDate\":\"12/01/2017\",\"LastTradeTime\":\"12:31pm\"}}"
+	exp := "{\"Bar\":{\"Name\":\"Bar\",\"Symbol\":\"B\",\"Price\":30.89,\"PreviousClose\":40.82,\"OpenPrice\":32.79,\"PercentChange\":\"+2%\",\"ChangeInPoints\":\"+2.0\",\"LastTradeDate\":\"12/01/2017\",\"LastTradeTime\":\"12:31pm\"},\"Foo\":{\"Name\":\"Foo\",\"Symbol\":\"F\",\"Price\":30.89,\"PreviousClose\":40.82,\"OpenPrice\":32.79,\"PercentChange\":\"+2%\",\"ChangeInPoints\":\"+2.0\",\"LastTradeDate\":\"12/01/2017\",\"LastTradeTime\":\"12:31pm\"}}"
 
-	exchanges["Foo"] = exchange.Exchange{
+	exchanges["F"] = exchange.Exchange{
 		Name:           "Foo",
 		Symbol:         "F",
-		Price:          30.89,
-		PreviousClose:  40.82,
-		OpenPrice:      32.79,
-		PercentChange:  "2%",
-		ChangeInPoints: "2.0",
+		Price:          exchange.MustParseDecimal("30.89"),
+		PreviousClose:  exchange.MustParseDecimal("40.82"),
+		OpenPrice:      exchange.MustParseDecimal("32.79"),
+		PercentChange:  exchange.MustParseDecimal("2"),
+		ChangeInPoints: exchange.MustParseDecimal("2.0"),
 		LastTradeDate:  "12/01/2017",
 		LastTradeTime:  "12:31pm",
 	}
 
-	exchanges["Bar"] = exchange.Exchange{
+	exchanges["B"] = exchange.Exchange{
 		Name:           "Bar",
 		Symbol:         "B",
-		Price:          30.89,
-		PreviousClose:  40.82,
-		OpenPrice:      32.79,
-		PercentChange:  "2%",
-		ChangeInPoints: "2.0",
+		Price:          exchange.MustParseDecimal("30.89"),
+		PreviousClose:  exchange.MustParseDecimal("40.82"),
+		OpenPrice:      exchange.MustParseDecimal("32.79"),
+		PercentChange:  exchange.MustParseDecimal("2"),
+		ChangeInPoints: exchange.MustParseDecimal("2.0"),
 		LastTradeDate:  "12/01/2017",
 		LastTradeTime:  "12:31pm",
 	}
 
-	jsonBody, _ := Join(exchanges)
+	jsonBody, _ := Join(&exchange.ExchangesResult{Exchanges: exchanges}, Options{Version: NameKeyedVersion})
+
+	if string(jsonBody) != exp {
+		t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
+	}
+}
+
+func TestJoinKeepsErrorsOutOfNameKeyedVersion(t *testing.T) {
+	result := &exchange.ExchangesResult{
+		Exchanges: map[string]exchange.Exchange{
+			"Foo": exchange.Exchange{Name: "Foo", Symbol: "F", Price: exchange.MustParseDecimal("30.89")},
+		},
+		Errors: map[string]error{
+			"BAR": &exchange.MissingSymbolError{Symbol: "BAR"},
+		},
+	}
+
+	exp := "{\"Foo\":{\"Name\":\"Foo\",\"Symbol\":\"F\",\"Price\":30.89,\"PreviousClose\":null,\"OpenPrice\":null,\"PercentChange\":\"\",\"ChangeInPoints\":\"\",\"LastTradeDate\":\"\",\"LastTradeTime\":\"\"}}"
+
+	jsonBody, _ := Join(result, Options{Version: NameKeyedVersion})
+
+	if string(jsonBody) != exp {
+		t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
+	}
+}
+
+func TestJoinDefaultsToSymbolKeyedVersionWithErrors(t *testing.T) {
+	result := exchange.FailedResult([]string{"AAPL"}, &exchange.MissingSymbolError{Symbol: "AAPL"})
+	exp := "{\"version\":2,\"exchanges\":{},\"errors\":{\"AAPL\":\"No quote was returned for 'AAPL'.\"}}"
+
+	jsonBody, _ := Join(result, Options{})
+
+	if string(jsonBody) != exp {
+		t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
+	}
+}
+
+func TestJoinWithSymbolKeyedVersion(t *testing.T) {
+	result := &exchange.ExchangesResult{
+		Exchanges: map[string]exchange.Exchange{
+			"GOOG":  exchange.Exchange{Name: "Alphabet Inc.", Symbol: "GOOG", Price: exchange.MustParseDecimal("30.89")},
+			"GOOGL": exchange.Exchange{Name: "Alphabet Inc.", Symbol: "GOOGL", Price: exchange.MustParseDecimal("32.79")},
+		},
+		Errors: map[string]error{
+			"BAR": &exchange.MissingSymbolError{Symbol: "BAR"},
+		},
+	}
+
+	exp := "{\"version\":2,\"exchanges\":{\"GOOG\":{\"Name\":\"Alphabet Inc.\",\"Symbol\":\"GOOG\",\"Price\":30.89,\"PreviousClose\":null,\"OpenPrice\":null,\"PercentChange\":\"\",\"ChangeInPoints\":\"\",\"LastTradeDate\":\"\",\"LastTradeTime\":\"\"},\"GOOGL\":{\"Name\":\"Alphabet Inc.\",\"Symbol\":\"GOOGL\",\"Price\":32.79,\"PreviousClose\":null,\"OpenPrice\":null,\"PercentChange\":\"\",\"ChangeInPoints\":\"\",\"LastTradeDate\":\"\",\"LastTradeTime\":\"\"}},\"errors\":{\"BAR\":\"No quote was returned for 'BAR'.\"}}"
+
+	jsonBody, err := Join(result, Options{Version: SymbolKeyedVersion})
+
+	if err != nil {
+		t.Fatalf("Join should build JSON response, but returned error: %v", err)
+	}
+
+	if string(jsonBody) != exp {
+		t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
+	}
+}
+
+func TestJoinWithNumericVersion(t *testing.T) {
+	result := &exchange.ExchangesResult{
+		Exchanges: map[string]exchange.Exchange{
+			"AAPL": exchange.Exchange{Name: "Apple Inc.", Symbol: "AAPL", Price: exchange.MustParseDecimal("155.39"), PercentChange: exchange.MustParseDecimal("1.24"), ChangeInPoints: exchange.MustParseDecimal("-1.91")},
+		},
+	}
+
+	exp := "{\"version\":3,\"exchanges\":{\"AAPL\":{\"Name\":\"Apple Inc.\",\"Symbol\":\"AAPL\",\"Price\":155.39,\"PreviousClose\":null,\"OpenPrice\":null,\"PercentChange\":1.24,\"ChangeInPoints\":-1.91,\"LastTradeDate\":\"\",\"LastTradeTime\":\"\",\"LastTrade\":null}}}"
+
+	jsonBody, err := Join(result, Options{Version: NumericVersion})
+
+	if err != nil {
+		t.Fatalf("Join should build JSON response, but returned error: %v", err)
+	}
+
+	if string(jsonBody) != exp {
+		t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
+	}
+}
+
+func TestJoinIncludesOnlyAvailableExtendedFieldsOnNumericVersion(t *testing.T) {
+	result := &exchange.ExchangesResult{
+		Exchanges: map[string]exchange.Exchange{
+			"AAPL": exchange.Exchange{Name: "Apple Inc.", Symbol: "AAPL", Volume: exchange.MustParseDecimal("21283769"), Currency: "USD"},
+		},
+	}
+
+	results := []struct {
+		version int
+		exp     string
+	}{
+		{SymbolKeyedVersion, "{\"version\":2,\"exchanges\":{\"AAPL\":{\"Name\":\"Apple Inc.\",\"Symbol\":\"AAPL\",\"Price\":null,\"PreviousClose\":null,\"OpenPrice\":null,\"PercentChange\":\"\",\"ChangeInPoints\":\"\",\"LastTradeDate\":\"\",\"LastTradeTime\":\"\"}}}"},
+		{NumericVersion, "{\"version\":3,\"exchanges\":{\"AAPL\":{\"Name\":\"Apple Inc.\",\"Symbol\":\"AAPL\",\"Price\":null,\"PreviousClose\":null,\"OpenPrice\":null,\"PercentChange\":null,\"ChangeInPoints\":null,\"LastTradeDate\":\"\",\"LastTradeTime\":\"\",\"LastTrade\":null,\"Volume\":21283769,\"Currency\":\"USD\"}}}"},
+	}
+
+	for _, r := range results {
+		jsonBody, _ := Join(result, Options{Version: r.version})
+
+		if string(jsonBody) != r.exp {
+			t.Fatalf("Built JSON response should be equal to %v, but is %v", r.exp, string(jsonBody))
+		}
+	}
+}
+
+func TestJoinWithSelectedFields(t *testing.T) {
+	result := &exchange.ExchangesResult{
+		Exchanges: map[string]exchange.Exchange{
+			"AAPL": exchange.Exchange{Name: "Apple Inc.", Symbol: "AAPL", Price: exchange.MustParseDecimal("155.39"), Currency: "USD"},
+		},
+	}
+
+	results := []struct {
+		version int
+		fields  []string
+		exp     string
+	}{
+		{NameKeyedVersion, []string{"price", "LastTradeDate"}, "{\"Apple Inc.\":{\"Symbol\":\"AAPL\",\"Price\":155.39,\"LastTradeDate\":\"\"}}"},
+		{NumericVersion, []string{"price", "Currency", "Volume"}, "{\"version\":3,\"exchanges\":{\"AAPL\":{\"Symbol\":\"AAPL\",\"Price\":155.39,\"Currency\":\"USD\"}}}"},
+	}
+
+	for _, r := range results {
+		jsonBody, err := Join(result, Options{Version: r.version, Fields: r.fields})
+
+		if err != nil {
+			t.Fatalf("Join should build JSON response, but returned error: %v", err)
+		}
+
+		if string(jsonBody) != r.exp {
+			t.Fatalf("Built JSON response should be equal to %v, but is %v", r.exp, string(jsonBody))
+		}
+	}
+}
+
+func TestJoinIncludesLastTradeOnlyOnNumericVersion(t *testing.T) {
+	lastTrade := exchange.ParseTradeTime("10/5/2017", "4:00pm", exchange.MarketLocation("AAPL", ""))
+	result := &exchange.ExchangesResult{
+		Exchanges: map[string]exchange.Exchange{
+			"AAPL": exchange.Exchange{Name: "Apple Inc.", Symbol: "AAPL", LastTrade: lastTrade},
+		},
+	}
+
+	exp := "{\"version\":3,\"exchanges\":{\"AAPL\":{\"Symbol\":\"AAPL\",\"LastTrade\":\"2017-10-05T16:00:00-04:00\"}}}"
+	jsonBody, err := Join(result, Options{Version: NumericVersion, Fields: []string{"LastTrade"}})
+
+	if err != nil || string(jsonBody) != exp {
+		t.Fatalf("Built JSON response should be equal to %v, but is %v (error: %v)", exp, string(jsonBody), err)
+	}
+
+	for _, version := range []int{NameKeyedVersion, SymbolKeyedVersion} {
+		if _, err := Join(result, Options{Version: version, Fields: []string{"LastTrade"}}); err == nil {
+			t.Fatalf("LastTrade should not be available on version %d, but no error was returned", version)
+		}
+	}
+}
+
+func TestJoinWithUnknownFields(t *testing.T) {
+	_, err := Join(&exchange.ExchangesResult{}, Options{Fields: []string{"Price", "Dividend"}})
+
+	fieldErr, ok := err.(*UnknownFieldError)
+
+	if !ok || len(fieldErr.Fields) != 1 || fieldErr.Fields[0] != "Dividend" {
+		t.Fatalf("Join with unknown field should return UnknownFieldError, but returned %v", err)
+	}
+}
+
+func TestJoinWithUnsupportedVersion(t *testing.T) {
+	_, err := Join(&exchange.ExchangesResult{}, Options{Version: 42})
+
+	if _, ok := err.(*UnsupportedVersionError); !ok {
+		t.Fatalf("Join with unknown version should return UnsupportedVersionError, but returned %v", err)
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	results := []struct {
+		body    string
+		indices string
+		fields  string
+		search  string
+		limit   int
+	}{
+		{"{\"indices\": [\"AAPL\", \"GOOGL\"]}", "AAPL,GOOGL", "", "", 0},
+		{"{\"indices\": [\"AAPL\"], \"fields\": [\"Symbol\", \"Price\"]}", "AAPL", "Symbol,Price", "", 0},
+		{"{\"search\": \"apple\", \"limit\": 5}", "", "", "apple", 5},
+		{"", "", "", "", 0},
+	}
+
+	for _, r := range results {
+		request := ParseRequest([]byte(r.body))
+
+		if indices := strings.Join(request.Indices, ","); indices != r.indices {
+			t.Fatalf("Request indices should be %s, but are %s", r.indices, indices)
+		}
+
+		if fields := strings.Join(request.Fields, ","); fields != r.fields {
+			t.Fatalf("Request fields should be %s, but are %s", r.fields, fields)
+		}
+
+		if request.Search != r.search || request.Limit != r.limit {
+			t.Fatalf("Request search should be '%s' limited to %d, but is '%s' limited to %d", r.search, r.limit, request.Search, request.Limit)
+		}
+	}
+}
+
+func TestJoinError(t *testing.T) {
+	requestErr := &UnknownFieldError{Fields: []string{"Dividend"}}
+
+	results := []struct {
+		version int
+		exp     string
+	}{
+		{NameKeyedVersion, "{\"error\":%s}"},
+		{SymbolKeyedVersion, "{\"version\":2,\"exchanges\":{},\"error\":%s}"},
+	}
+
+	message, _ := json.Marshal(requestErr.Error())
+
+	for _, r := range results {
+		exp := fmt.Sprintf(r.exp, message)
+		jsonBody, _ := JoinError(requestErr, Options{Version: r.version})
+
+		if string(jsonBody) != exp {
+			t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
+		}
+	}
+}
+
+func TestJoinSearch(t *testing.T) {
+	exp := "{\"search\":\"nothing\",\"results\":[]}"
+
+	jsonBody, _ := JoinSearch("nothing", nil)
 
 	if string(jsonBody) != exp {
 		t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
@@ -0,0 +1,136 @@
+package indices
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+type Aliases map[string]string
+
+type Normalization struct {
+	Symbols  []string
+	Resolved map[string]string
+}
+
+type aliasFileError struct {
+	path string
+	err  error
+}
+
+func LoadAliases(path string) (Aliases, error) {
+	content, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, &aliasFileError{path, err}
+	}
+
+	entries := make(map[string]string)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		err = parseYAMLAliases(string(content), entries)
+	default:
+		err = json.Unmarshal(content, &entries)
+	}
+
+	if err != nil {
+		return nil, &aliasFileError{path, err}
+	}
+
+	aliases := make(Aliases, len(entries))
+
+	for alias, symbol := range entries {
+		aliases[canonical(alias)] = canonical(symbol)
+	}
+
+	return aliases, nil
+}
+
+func Normalize(symbols []string, aliases Aliases) Normalization {
+	normalization := Normalization{Resolved: make(map[string]string, len(symbols))}
+	seen := make(map[string]bool, len(symbols))
+
+	for _, original := range symbols {
+		symbol := canonical(original)
+
+		if symbol == "" {
+			continue
+		}
+
+		if resolved, ok := aliases[symbol]; ok {
+			symbol = resolved
+		}
+
+		normalization.Resolved[original] = symbol
+
+		if !seen[symbol] {
+			seen[symbol] = true
+			normalization.Symbols = append(normalization.Symbols, symbol)
+		}
+	}
+
+	return normalization
+}
+
+func canonical(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+func parseYAMLAliases(content string, entries map[string]string) error {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") || text == "---" {
+			continue
+		}
+
+		separator := strings.Index(text, ":")
+
+		if separator <= 0 {
+			return fmt.Errorf("line %d is not an 'alias: symbol' entry", line)
+		}
+
+		entries[unquote(text[:separator])] = unquote(stripComment(text[separator+1:]))
+	}
+
+	return scanner.Err()
+}
+
+func stripComment(value string) string {
+	var quote byte
+
+	for i := 0; i < len(value); i++ {
+		switch {
+		case quote != 0:
+			if value[i] == quote {
+				quote = 0
+			}
+		case value[i] == '"' || value[i] == '\'':
+			quote = value[i]
+		case value[i] == '#' && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t'):
+			return value[:i]
+		}
+	}
+
+	return value
+}
+
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+func (err *aliasFileError) Error() string {
+	return fmt.Sprintf("There was a problem when loading aliases from '%s': %v", err.path, err.err)
+}
@@ -0,0 +1,100 @@
+package indices
+
+import (
+	"github.com/docStonehenge/exchange_fetcher/exchange"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeUppercasesTrimsAndDeduplicates(t *testing.T) {
+	normalization := Normalize([]string{" aapl", "AAPL", "googl ", "", "Aapl"}, nil)
+
+	if symbols := strings.Join(normalization.Symbols, ","); symbols != "AAPL,GOOGL" {
+		t.Fatalf("Normalized symbols should be %s, but are %s", "AAPL,GOOGL", symbols)
+	}
+
+	for original, expected := range map[string]string{" aapl": "AAPL", "Aapl": "AAPL", "googl ": "GOOGL"} {
+		if resolved := normalization.Resolved[original]; resolved != expected {
+			t.Fatalf("Symbol '%s' should resolve to %s, but resolves to %s", original, expected, resolved)
+		}
+	}
+
+	if _, ok := normalization.Resolved[""]; ok {
+		t.Fatalf("Empty symbols should be dropped, but mapping is %v", normalization.Resolved)
+	}
+}
+
+func TestNormalizeMapsAliases(t *testing.T) {
+	aliases := Aliases{"SPX": "^GSPC", "BRK.B": "BRK-B"}
+
+	normalization := Normalize([]string{"spx", "^GSPC", "brk.b", "BRK-B"}, aliases)
+
+	if symbols := strings.Join(normalization.Symbols, ","); symbols != "^GSPC,BRK-B" {
+		t.Fatalf("Aliases should be resolved and deduplicated, but symbols are %s", symbols)
+	}
+
+	if resolved := normalization.Resolved["brk.b"]; resolved != "BRK-B" {
+		t.Fatalf("Alias should resolve to %s, but resolves to %s", "BRK-B", resolved)
+	}
+}
+
+func TestLoadAliases(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "exchange_fetcher")
+	defer os.RemoveAll(dir)
+
+	results := []struct {
+		file, content string
+	}{
+		{"aliases.json", `{"spx": "^GSPC", "BRK.B": "BRK-B"}`},
+		{"aliases.json", `{"spx": " ^gspc", "BRK.B": "brk-b "}`},
+		{"aliases.yml", "# S&P 500\nspx: \"^GSPC\"\nBRK.B: BRK-B\n"},
+		{"aliases.yml", "spx: ^gspc # S&P 500\nBRK.B: \"brk-b\"  # Berkshire Hathaway\n"},
+	}
+
+	for _, r := range results {
+		path := filepath.Join(dir, r.file)
+		ioutil.WriteFile(path, []byte(r.content), 0644)
+
+		aliases, err := LoadAliases(path)
+
+		if err != nil {
+			t.Fatalf("Aliases should be loaded from %s, but returned error: %v", r.file, err)
+		}
+
+		if aliases["SPX"] != "^GSPC" || aliases["BRK.B"] != "BRK-B" {
+			t.Fatalf("Aliases from %s should map uppercased aliases to uppercased symbols, but are %v", r.file, aliases)
+		}
+	}
+}
+
+func TestLoadAliasesWithInvalidFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "exchange_fetcher")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "aliases.yaml")
+	ioutil.WriteFile(path, []byte("SPX ^GSPC\n"), 0644)
+
+	for _, path := range []string{path, filepath.Join(dir, "missing.json")} {
+		if _, err := LoadAliases(path); err == nil || !strings.Contains(err.Error(), path) {
+			t.Fatalf("Loading %s should return error naming the file, but returned %v", path, err)
+		}
+	}
+}
+
+func TestJoinIncludesResolvedSymbols(t *testing.T) {
+	result := &exchange.ExchangesResult{
+		Exchanges: map[string]exchange.Exchange{},
+		Resolved:  map[string]string{"spx": "^GSPC"},
+	}
+
+	exp := "{\"version\":2,\"exchanges\":{},\"symbols\":{\"spx\":\"^GSPC\"}}"
+
+	jsonBody, _ := Join(result, Options{Version: SymbolKeyedVersion})
+
+	if string(jsonBody) != exp {
+		t.Fatalf("Built JSON response should be equal to %v, but is %v", exp, string(jsonBody))
+	}
+}
@@ -47,7 +47,7 @@ func TestParsingIndicesCorrectlyFromMessageQueue(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "exchange_fetcher", "-mq")
+	cmd := exec.CommandContext(ctx, "exchange_fetcher", "-mq", "-replay", "testdata/replay")
 	var input bytes.Buffer
 	var output bytes.Buffer
 
@@ -80,7 +80,7 @@ func TestParsingIndicesCorrectlyFromMessageQueue(t *testing.T) {
 
 	msg := <-msgs
 
-	if !strings.Contains(string(msg.Body), "{\"Apple Inc.\":{\"Name\":\"Apple Inc.\",\"Symbol\":\"AAPL\"") {
+	if !strings.Contains(string(msg.Body), "{\"version\":2,\"exchanges\":{\"AAPL\":{\"Name\":\"Apple Inc.\",\"Symbol\":\"AAPL\"") {
 		t.Fatalf("Message sent to client should be a JSON with correct values, but is %s", string(msg.Body))
 	}
 }
@@ -88,7 +88,7 @@ func TestParsingIndicesCorrectlyFromMessageQueue(t *testing.T) {
 func TestParsingOneIndexCorrectlyFromCommandLine(t *testing.T) {
 	index := "AAPL"
 
-	cmd := exec.Command("exchange_fetcher", "-indices="+index)
+	cmd := exec.Command("exchange_fetcher", "-replay", "testdata/replay", "-indices="+index)
 	var output bytes.Buffer
 
 	cmd.Stdout = &output
@@ -109,7 +109,7 @@ func TestParsingOneIndexCorrectlyFromCommandLine(t *testing.T) {
 func TestParsingMoreThanOneIndexCorrectlyFromCommandLine(t *testing.T) {
 	indices := "AAPL, GOOGL, MGLU3.SA"
 
-	cmd := exec.Command("exchange_fetcher", "-indices", indices)
+	cmd := exec.Command("exchange_fetcher", "-replay", "testdata/replay", "-indices", indices)
 	var output bytes.Buffer
 
 	cmd.Stdout = &output